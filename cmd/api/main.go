@@ -2,6 +2,8 @@ package main
 
 import (
 	"log"
+	"time"
+	_ "time/tzdata" // fusos IANA das preferências mesmo em imagens sem tzdata
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -9,11 +11,15 @@ import (
 	recovermw "github.com/gofiber/fiber/v2/middleware/recover"
 
 	"goTasks/internal/auth"
+	"goTasks/internal/chat"
 	"goTasks/internal/config"
 	"goTasks/internal/db"
 	"goTasks/internal/handlers"
+	"goTasks/internal/mail"
 	"goTasks/internal/ws"
 	"goTasks/internal/notify"
+	"goTasks/internal/trash"
+	"goTasks/internal/webhook"
 )
 
 func main() {
@@ -33,7 +39,7 @@ func main() {
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "*",
 		AllowMethods: "GET,POST,PUT,PATCH,DELETE,OPTIONS",
-		AllowHeaders: "Content-Type, Authorization",
+		AllowHeaders: "Content-Type, Authorization, Last-Event-ID",
 	}))
 
 	// Health
@@ -45,18 +51,56 @@ func main() {
 
 	// WebSocket Hub
 	hub := ws.NewHub()
+	// Webhooks: recebem os eventos do hub (registrar antes de Run)
+	webhooks := webhook.NewDispatcher(database)
+	webhooks.Listen(hub)
+	webhooks.Start()
+	chatNotifier := chat.NewNotifier(database, cfg.AppURL, chat.DefaultInterval)
+	chatNotifier.Listen(hub)
+	chatNotifier.Start()
 	go hub.Run()
-	app.Get("/ws", ws.UpgradeWithAuth(cfg.JWTSecret, hub))
+	hub.SetAudience(handlers.TaskAudience(database))
+	revoked := auth.SessionRevoked(database)
+	wsTickets := ws.NewTicketStore(30 * time.Second)
+	app.Get("/ws", ws.UpgradeWithAuth(cfg.JWTSecret, hub, wsTickets, revoked))
+
+	// E-mail: SMTP se configurado, senão sink em arquivos (MAIL_DIR); sem nenhum, canal desativado
+	var mailer *mail.Queue
+	if cfg.SMTPHost != "" {
+		mailer = mail.NewQueue(database, mail.NewSMTPSender(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, cfg.SMTPPassword, cfg.MailFrom))
+	} else if cfg.MailDir != "" {
+		mailer = mail.NewQueue(database, mail.NewFileSender(cfg.MailDir, cfg.MailFrom))
+	}
+	if mailer != nil {
+		mailer.Start()
+	}
+
+	notifier := notify.NewService(database, hub, mailer, cfg.AppURL)
 
 	// Handlers
-	authHandler := handlers.NewAuthHandler(database, cfg.JWTSecret)
-	taskHandler := handlers.NewTaskHandler(database, hub)
-	commentHandler := handlers.NewCommentHandler(database, hub)
-	notificationsHandler := handlers.NewNotificationsHandler(database)
+	authHandler := handlers.NewAuthHandler(database, cfg.JWTSecret, hub)
+	taskHandler := handlers.NewTaskHandler(database, hub, notifier)
+	commentHandler := handlers.NewCommentHandler(database, hub, notifier)
+	notificationsHandler := handlers.NewNotificationsHandler(database, hub)
+	presenceHandler := handlers.NewPresenceHandler(database, hub)
+	preferencesHandler := handlers.NewPreferencesHandler(database)
+	reminderHandler := handlers.NewReminderHandler(database)
+	webhookHandler := handlers.NewWebhookHandler(database, webhooks)
+	chatHandler := handlers.NewChatChannelHandler(database, chatNotifier)
+	labelHandler := handlers.NewLabelHandler(database)
+	projectHandler := handlers.NewProjectHandler(database, hub)
+	orgHandler := handlers.NewOrgHandler(database, hub)
+	workflowHandler := handlers.NewWorkflowHandler(database, hub)
+	checklistHandler := handlers.NewChecklistHandler(database, hub)
+	dependencyHandler := handlers.NewDependencyHandler(database, hub)
 
 	// Scheduler de notificações
-	scheduler := notify.NewScheduler(database, hub)
+	scheduler := notify.NewScheduler(database, notifier)
 	scheduler.Start()
+	// Limpeza da lixeira (só na réplica líder)
+	purger := trash.NewPurger(database)
+	purger.Start()
+	adminHandler := handlers.NewAdminHandler(database, scheduler)
 
 	api := app.Group("/api")
 	// rotas públicas
@@ -74,7 +118,9 @@ func main() {
 	aiHandler := handlers.NewAIHandler(database, aiClient, cfg.AIModel, cfg.AILimitDaily)
 
 	// grupo protegido
-	apiAuth := app.Group("/api", auth.RequireJWT(cfg.JWTSecret))
+	apiAuth := app.Group("/api", auth.RequireJWT(cfg.JWTSecret, revoked))
+	apiAuth.Post("/auth/logout", authHandler.Logout)
+	apiAuth.Post("/ws/ticket", wsTickets.IssueHandler())
 	apiAuth.Post("/ai/tasks/:id/summary", aiHandler.SummarizeTask)
 	// poderia ter: /ai/tasks/:id/next-steps, /ai/chat
 	apiAuth.Get("/tasks", taskHandler.List)
@@ -82,12 +128,89 @@ func main() {
 	apiAuth.Get("/tasks/:id", taskHandler.GetByID)
 	apiAuth.Patch("/tasks/:id", taskHandler.Update)
 	apiAuth.Delete("/tasks/:id", taskHandler.Delete)
+	apiAuth.Post("/tasks/:id/move", taskHandler.Move)
+	apiAuth.Get("/tasks/:id/history", taskHandler.History)
+	apiAuth.Post("/tasks/:id/restore", taskHandler.RestoreFromTrash)
+	apiAuth.Get("/trash", taskHandler.Trash)
+	apiAuth.Post("/tasks/:id/history/:revisionId/restore", taskHandler.Restore)
+	apiAuth.Get("/board", taskHandler.Board)
+	apiAuth.Put("/tasks/:id/watch", taskHandler.Watch)
+	apiAuth.Delete("/tasks/:id/watch", taskHandler.Unwatch)
+
+	apiAuth.Get("/tasks/:id/checklist", checklistHandler.List)
+	apiAuth.Post("/tasks/:id/checklist", checklistHandler.Create)
+	apiAuth.Put("/tasks/:id/checklist/order", checklistHandler.Reorder)
+	apiAuth.Patch("/tasks/:id/checklist/:itemId", checklistHandler.Update)
+	apiAuth.Delete("/tasks/:id/checklist/:itemId", checklistHandler.Delete)
+
+	apiAuth.Get("/tasks/:id/dependencies", dependencyHandler.List)
+	apiAuth.Post("/tasks/:id/dependencies", dependencyHandler.Add)
+	apiAuth.Delete("/tasks/:id/dependencies/:blockerId", dependencyHandler.Remove)
+
+	apiAuth.Get("/org", orgHandler.Get)
+	apiAuth.Patch("/org", orgHandler.Update)
+	apiAuth.Get("/org/users", orgHandler.Users)
+	apiAuth.Post("/org/users", orgHandler.AddUser)
+	apiAuth.Patch("/org/users/:id", orgHandler.UpdateUser)
+
+	apiAuth.Get("/projects", projectHandler.List)
+	apiAuth.Post("/projects", projectHandler.Create)
+	apiAuth.Get("/projects/:id", projectHandler.Get)
+	apiAuth.Patch("/projects/:id", projectHandler.Update)
+	apiAuth.Delete("/projects/:id", projectHandler.Delete)
+	apiAuth.Post("/projects/:id/members", projectHandler.AddMember)
+	apiAuth.Patch("/projects/:id/members/:userId", projectHandler.UpdateMember)
+	apiAuth.Delete("/projects/:id/members/:userId", projectHandler.RemoveMember)
+	apiAuth.Get("/projects/:id/workflow", workflowHandler.Get)
+	apiAuth.Put("/projects/:id/workflow", workflowHandler.Put)
+
+	apiAuth.Get("/labels", labelHandler.List)
+	apiAuth.Post("/labels", labelHandler.Create)
+	apiAuth.Patch("/labels/:id", labelHandler.Update)
+	apiAuth.Delete("/labels/:id", labelHandler.Delete)
+
+	apiAuth.Get("/tasks/:id/reminders", reminderHandler.List)
+	apiAuth.Post("/tasks/:id/reminders", reminderHandler.Create)
+	apiAuth.Delete("/tasks/:id/reminders/:reminderId", reminderHandler.Delete)
 
 	apiAuth.Get("/tasks/:id/comments", commentHandler.ListByTask)
 	apiAuth.Post("/tasks/:id/comments", commentHandler.CreateOnTask)
+	apiAuth.Delete("/tasks/:id/comments/:commentId", commentHandler.Delete)
 
 	apiAuth.Get("/notifications", notificationsHandler.List)
+	apiAuth.Get("/notifications/unread-count", notificationsHandler.UnreadCount)
+	apiAuth.Post("/notifications/read-all", notificationsHandler.ReadAll)
 	apiAuth.Patch("/notifications/:id/read", notificationsHandler.MarkRead)
+	apiAuth.Delete("/notifications/:id", notificationsHandler.Dismiss)
+	apiAuth.Get("/webhooks", webhookHandler.List)
+	apiAuth.Post("/webhooks", webhookHandler.Create)
+	apiAuth.Patch("/webhooks/:id", webhookHandler.Update)
+	apiAuth.Delete("/webhooks/:id", webhookHandler.Delete)
+	apiAuth.Get("/webhooks/:id/deliveries", webhookHandler.Deliveries)
+	apiAuth.Post("/webhooks/:id/deliveries/:deliveryId/redeliver", webhookHandler.Redeliver)
+
+	apiAuth.Get("/chat-channels", chatHandler.List)
+	apiAuth.Post("/chat-channels", chatHandler.Create)
+	apiAuth.Patch("/chat-channels/:id", chatHandler.Update)
+	apiAuth.Delete("/chat-channels/:id", chatHandler.Delete)
+	apiAuth.Post("/chat-channels/:id/test", chatHandler.Test)
+
+	admin := apiAuth.Group("/admin", auth.RequireRole("admin"))
+	admin.Get("/scheduler", adminHandler.SchedulerHealth)
+	admin.Get("/trash", adminHandler.TrashSettings)
+	admin.Put("/trash", adminHandler.UpdateTrashSettings)
+	admin.Get("/orgs", orgHandler.ListAll)
+	admin.Post("/orgs", orgHandler.Create)
+	admin.Post("/orgs/:id/users", orgHandler.AddUserTo)
+
+	apiAuth.Get("/me/notification-preferences", preferencesHandler.Get)
+	apiAuth.Put("/me/notification-preferences", preferencesHandler.Update)
+
+	// SSE: alternativa ao /ws para quem não consegue fazer upgrade
+	apiAuth.Get("/events", ws.ServeSSE(hub, revoked))
+
+	apiAuth.Get("/presence", presenceHandler.Online)
+	apiAuth.Get("/tasks/:id/presence", presenceHandler.TaskViewers)
 
 	// Swagger, WS etc.
 	log.Printf("API ouvindo em http://localhost:%s", cfg.Port)
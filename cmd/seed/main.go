@@ -21,10 +21,14 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// organização
+	org := models.Organization{Name: "Exemplo"}
+	database.Where(models.Organization{Name: org.Name}).FirstOrCreate(&org)
+
 	// users
-	admin := models.User{Name: "Admin", Email: "admin@example.com", Role: "admin"}
+	admin := models.User{Name: "Admin", Email: "admin@example.com", Role: models.RoleAdmin, OrgID: org.ID}
 	admin.PasswordHash = hash("admin123")
-	user := models.User{Name: "User", Email: "user@example.com", Role: "user"}
+	user := models.User{Name: "User", Email: "user@example.com", Role: models.RoleUser, OrgID: org.ID}
 	user.PasswordHash = hash("user123")
 
 	database.Where(models.User{Email: admin.Email}).FirstOrCreate(&admin)
@@ -32,8 +36,8 @@ func main() {
 
 	// tasks for user
 	now := time.Now().UTC()
-	t1 := models.Task{Title: "Planejar sprint", Description: "Backlog grooming", Status: models.StatusTodo, OwnerID: user.ID, DueDate: &now}
-	t2 := models.Task{Title: "Refatorar serviço", Description: "Melhorar performance", Status: models.StatusDoing, OwnerID: user.ID}
+	t1 := models.Task{Title: "Planejar sprint", Description: "Backlog grooming", Status: models.StatusTodo, OwnerID: user.ID, OrgID: org.ID, DueDate: &now}
+	t2 := models.Task{Title: "Refatorar serviço", Description: "Melhorar performance", Status: models.StatusDoing, OwnerID: user.ID, OrgID: org.ID}
 	database.Create(&t1)
 	database.Create(&t2)
 
@@ -9,10 +9,24 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func CreateToken(userID uint, role string, secret string) (string, error) {
+// Claims resume os dados de um access token válido.
+type Claims struct {
+	UserID    uint
+	Role      string
+	OrgID     uint
+	Version   int // versão da sessão do usuário (User.TokenVersion) na emissão
+	ExpiresAt time.Time
+}
+
+// Revoked informa se a sessão do usuário na versão dada foi revogada.
+type Revoked func(userID uint, version int) bool
+
+func CreateToken(userID uint, role string, orgID uint, version int, secret string) (string, error) {
 	claims := jwt.MapClaims{
 		"sub":  userID,
 		"role": role,
+		"org":  orgID,
+		"ver":  version,
 		"exp":  time.Now().Add(2 * time.Hour).Unix(),
 		"iat":  time.Now().Unix(),
 	}
@@ -20,10 +34,12 @@ func CreateToken(userID uint, role string, secret string) (string, error) {
 	return t.SignedString([]byte(secret))
 }
 
-func CreateRefreshToken(userID uint, role string, secret string) (string, error) {
+func CreateRefreshToken(userID uint, role string, orgID uint, version int, secret string) (string, error) {
 	claims := jwt.MapClaims{
 		"sub":  userID,
 		"role": role,
+		"org":  orgID,
+		"ver":  version,
 		"type": "refresh",
 		"exp":  time.Now().Add(14 * 24 * time.Hour).Unix(),
 		"iat":  time.Now().Unix(),
@@ -45,28 +61,63 @@ func Parse(tokenStr, secret string) (*jwt.Token, error) {
 	return parseToken(tokenStr, secret)
 }
 
-func RequireJWT(secret string) fiber.Handler {
+// ParseAccess valida um access token (refresh tokens são recusados) e extrai as claims.
+func ParseAccess(tokenStr, secret string) (Claims, error) {
+	tok, err := parseToken(tokenStr, secret)
+	if err != nil || !tok.Valid {
+		return Claims{}, errors.New("invalid token")
+	}
+	claims, ok := tok.Claims.(jwt.MapClaims)
+	if !ok {
+		return Claims{}, errors.New("invalid claims")
+	}
+	if claims["type"] == "refresh" {
+		return Claims{}, errors.New("refresh token not accepted")
+	}
+	sub, ok := claims["sub"].(float64)
+	if !ok {
+		return Claims{}, errors.New("invalid subject")
+	}
+	role, _ := claims["role"].(string)
+	org, _ := claims["org"].(float64)
+	ver, _ := claims["ver"].(float64)
+	exp, _ := claims["exp"].(float64)
+	return Claims{UserID: uint(sub), Role: role, OrgID: uint(org), Version: int(ver), ExpiresAt: time.Unix(int64(exp), 0)}, nil
+}
+
+// RequireJWT exige um Bearer token válido; com revoked != nil, recusa sessões encerradas.
+func RequireJWT(secret string, revoked Revoked) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
 		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "missing token"})
 		}
 		tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
-		tok, err := parseToken(tokenStr, secret)
-		if err != nil || !tok.Valid {
+		claims, err := ParseAccess(tokenStr, secret)
+		if err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid token"})
 		}
-		claims, ok := tok.Claims.(jwt.MapClaims)
-		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid claims"})
+		if revoked != nil && revoked(claims.UserID, claims.Version) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "session revoked"})
 		}
-		sub, ok := claims["sub"].(float64)
-		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid subject"})
-		}
-		role, _ := claims["role"].(string)
-		c.Locals("userID", uint(sub))
-		c.Locals("userRole", role)
+		c.Locals("userID", claims.UserID)
+		c.Locals("userRole", claims.Role)
+		c.Locals("orgID", claims.OrgID)
+		c.Locals("tokenClaims", claims)
 		return c.Next()
 	}
-}
\ No newline at end of file
+}
+
+
+// RequireRole restringe a rota aos papéis informados (usar depois de RequireJWT).
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, _ := c.Locals("userRole").(string)
+		for _, r := range roles {
+			if r == role {
+				return c.Next()
+			}
+		}
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "not allowed"})
+	}
+}
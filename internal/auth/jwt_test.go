@@ -0,0 +1,24 @@
+package auth
+
+import "testing"
+
+func TestParseAccessRejectsRefreshTokens(t *testing.T) {
+	access, err := CreateToken(7, "user", 3, 2, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ParseAccess(access, "secret")
+	if err != nil || claims.UserID != 7 || claims.Role != "user" || claims.OrgID != 3 || claims.Version != 2 {
+		t.Fatalf("access token: %+v %v", claims, err)
+	}
+	if _, err := ParseAccess(access, "other"); err == nil {
+		t.Fatal("a token signed with another secret must be rejected")
+	}
+	refresh, err := CreateRefreshToken(7, "user", 3, 2, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseAccess(refresh, "secret"); err == nil {
+		t.Fatal("a refresh token must not be accepted as an access token")
+	}
+}
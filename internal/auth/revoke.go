@@ -0,0 +1,18 @@
+package auth
+
+import (
+	"gorm.io/gorm"
+
+	"goTasks/internal/models"
+)
+
+// SessionRevoked compara a versão do token com User.TokenVersion (incrementada no logout).
+func SessionRevoked(db *gorm.DB) Revoked {
+	return func(userID uint, version int) bool {
+		var u models.User
+		if err := db.Select("id", "token_version").First(&u, "id = ?", userID).Error; err != nil {
+			return true
+		}
+		return u.TokenVersion != version
+	}
+}
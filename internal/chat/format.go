@@ -0,0 +1,128 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"goTasks/internal/models"
+)
+
+// maxAttachments limita o tamanho de uma mensagem agrupada
+const maxAttachments = 20
+
+// Message é o corpo aceito pelos incoming webhooks do Slack e do Mattermost.
+type Message struct {
+	Text        string       `json:"text"`
+	Username    string       `json:"username,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+type Attachment struct {
+	Fallback  string `json:"fallback"`
+	Color     string `json:"color,omitempty"`
+	Title     string `json:"title,omitempty"`
+	TitleLink string `json:"title_link,omitempty"`
+	Text      string `json:"text,omitempty"`
+}
+
+// Line é um item a publicar: uma notificação ou um evento de tarefa.
+type Line struct {
+	Text  string
+	Title string
+	Link  string
+	Color string
+}
+
+var eventText = map[string]string{
+	"task.created": "Nova tarefa",
+	"task.updated": "Tarefa atualizada",
+	"task.deleted": "Tarefa removida",
+}
+
+// TaskEvents lista os eventos de tarefa que um canal pode receber.
+var TaskEvents = []string{"task.created", "task.updated", "task.deleted"}
+
+func taskLink(appURL string, taskID uint) string {
+	return fmt.Sprintf("%s/tasks?task=%d", strings.TrimRight(appURL, "/"), taskID)
+}
+
+// FormatNotification converte uma notificação em linha de chat.
+func FormatNotification(n models.Notification, appURL string) Line {
+	color := "#439FE0"
+	if n.Type == models.NotificationOverdue {
+		color = "danger"
+	} else if n.Type == models.NotificationDueSoon || n.Type == models.NotificationReminder {
+		color = "warning"
+	}
+	return Line{Text: n.Message, Title: n.Message, Link: taskLink(appURL, n.TaskID), Color: color}
+}
+
+// FormatTaskEvent converte task.created/updated/deleted; false para payloads desconhecidos.
+func FormatTaskEvent(typ string, payload interface{}, appURL string) (Line, bool) {
+	label, ok := eventText[typ]
+	if !ok {
+		return Line{}, false
+	}
+	if t, ok := payload.(models.Task); ok {
+		text := fmt.Sprintf("%s: %s (%s)", label, t.Title, t.Status)
+		return Line{Text: text, Title: t.Title, Link: taskLink(appURL, t.ID), Color: "good"}, true
+	}
+	// task.deleted só traz o id
+	var ref struct {
+		ID interface{} `json:"id"`
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil || json.Unmarshal(raw, &ref) != nil || ref.ID == nil {
+		return Line{}, false
+	}
+	return Line{Text: fmt.Sprintf("%s: #%v", label, ref.ID), Color: "#999999"}, true
+}
+
+// projectOf devolve o projeto da tarefa do evento (0 = sem projeto).
+func projectOf(payload interface{}) uint {
+	if t, ok := payload.(models.Task); ok {
+		if t.ProjectID == nil {
+			return 0
+		}
+		return *t.ProjectID
+	}
+	var ref struct {
+		ProjectID uint `json:"projectId"`
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil || json.Unmarshal(raw, &ref) != nil {
+		return 0
+	}
+	return ref.ProjectID
+}
+
+// Build monta a mensagem: uma linha vira um anexo; várias viram um resumo com um anexo por item.
+func Build(lines []Line) Message {
+	msg := Message{Username: "goTasks"}
+	if len(lines) == 1 {
+		msg.Text = escape(lines[0].Text)
+	} else {
+		msg.Text = fmt.Sprintf("%d atualizações no goTasks", len(lines))
+	}
+	for i, l := range lines {
+		if i == maxAttachments {
+			msg.Attachments = append(msg.Attachments, Attachment{
+				Fallback: fmt.Sprintf("… e mais %d", len(lines)-i),
+				Text:     fmt.Sprintf("… e mais %d", len(lines)-i),
+			})
+			break
+		}
+		a := Attachment{Fallback: escape(l.Text), Color: l.Color, Title: escape(l.Title), TitleLink: l.Link}
+		if l.Text != l.Title {
+			a.Text = escape(l.Text)
+		}
+		msg.Attachments = append(msg.Attachments, a)
+	}
+	return msg
+}
+
+// escape aplica o escape de controle do Slack (&, <, >) em texto livre.
+func escape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
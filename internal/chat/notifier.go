@@ -0,0 +1,260 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"goTasks/internal/models"
+	"goTasks/internal/notify"
+	"goTasks/internal/outbound"
+	"goTasks/internal/ws"
+)
+
+const (
+	// DefaultInterval: no máximo uma mensagem por canal nesse intervalo; o resto é agrupado
+	DefaultInterval = 10 * time.Second
+	maxPending      = 100
+	sendTimeout     = 10 * time.Second
+)
+
+type batch struct {
+	url      string
+	lines    []Line
+	timer    *time.Timer
+	lastSent time.Time
+}
+
+// Notifier publica notificações e eventos de tarefa em canais de chat, agrupando
+// o que chega dentro do intervalo mínimo de cada canal.
+type Notifier struct {
+	db       *gorm.DB
+	client   *http.Client
+	appURL   string
+	interval time.Duration
+	events   chan ws.Event
+	stop     chan struct{}
+
+	mu      sync.Mutex
+	batches map[uint]*batch
+}
+
+func NewNotifier(db *gorm.DB, appURL string, interval time.Duration) *Notifier {
+	return &Notifier{
+		db:       db,
+		client:   outbound.Client(sendTimeout),
+		appURL:   appURL,
+		interval: interval,
+		events:   make(chan ws.Event, 256),
+		stop:     make(chan struct{}),
+		batches:  make(map[uint]*batch),
+	}
+}
+
+// Listen assina os eventos do hub (chamar antes de hub.Run).
+func (n *Notifier) Listen(hub *ws.Hub) {
+	hub.AddListener(func(ev ws.Event) {
+		if ev.Type != "notification.created" && eventText[ev.Type] == "" {
+			return
+		}
+		select {
+		case n.events <- ev:
+		default:
+			log.Printf("chat: event %d (%s) dropped, queue full", ev.ID, ev.Type)
+		}
+	})
+}
+
+func (n *Notifier) Start() {
+	go func() {
+		for {
+			select {
+			case ev := <-n.events:
+				if err := n.handle(ev); err != nil {
+					log.Printf("chat event error: %v", err)
+				}
+			case <-n.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (n *Notifier) Stop() {
+	close(n.stop)
+}
+
+// SendTest envia uma mensagem avulsa (sem agrupamento) para conferir a URL do canal.
+func (n *Notifier) SendTest(ctx context.Context, ch models.ChatChannel) error {
+	msg := Build([]Line{{Text: fmt.Sprintf("Canal \"%s\" conectado ao goTasks.", ch.Name), Color: "good"}})
+	_, err := n.post(ctx, ch.URL, msg)
+	return err
+}
+
+func (n *Notifier) handle(ev ws.Event) error {
+	if ev.Type == "notification.created" {
+		notif, ok := ev.Payload.(models.Notification)
+		if !ok {
+			return nil
+		}
+		// canais pessoais respeitam o horário de silêncio, como o push
+		p, err := notify.LoadPreferences(n.db, notif.UserID)
+		if err != nil || !p.Wants(notif.Type, models.ChannelChat) || p.InQuietHours(time.Now()) {
+			return err
+		}
+		var channels []models.ChatChannel
+		if err := n.db.Where("user_id = ? AND project_id IS NULL AND active = ? AND notifications = ?", notif.UserID, true, true).Find(&channels).Error; err != nil {
+			return err
+		}
+		line := FormatNotification(notif, n.appURL)
+		for _, ch := range channels {
+			n.queue(ch, line)
+		}
+		return nil
+	}
+
+	line, ok := FormatTaskEvent(ev.Type, ev.Payload, n.appURL)
+	if !ok {
+		return nil
+	}
+	// canais pessoais de quem recebe o evento e o canal do projeto da tarefa
+	cond := n.db.Where("project_id IS NULL AND user_id IN ?", append([]uint{0}, ev.To...))
+	if project := projectOf(ev.Payload); project != 0 {
+		cond = cond.Or("project_id = ?", project)
+	}
+	var channels []models.ChatChannel
+	if err := n.db.Where("active = ?", true).Where(cond).Find(&channels).Error; err != nil {
+		return err
+	}
+	users := make([]uint, 0, len(channels))
+	for _, ch := range channels {
+		users = append(users, ch.UserID)
+	}
+	prefs, err := notify.LoadPreferencesFor(n.db, users)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, ch := range channels {
+		if !contains(ch.TaskEvents, ev.Type) || (ch.ProjectID == nil && prefs[ch.UserID].InQuietHours(now)) {
+			continue
+		}
+		n.queue(ch, line)
+	}
+	return nil
+}
+
+// queue acumula a linha e agenda o envio respeitando o intervalo do canal.
+func (n *Notifier) queue(ch models.ChatChannel, l Line) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	b, ok := n.batches[ch.ID]
+	if !ok {
+		b = &batch{}
+		n.batches[ch.ID] = b
+	}
+	b.url = ch.URL
+	b.lines = append(b.lines, l)
+	if len(b.lines) > maxPending {
+		b.lines = b.lines[len(b.lines)-maxPending:]
+	}
+	n.scheduleLocked(ch.ID, b, time.Until(b.lastSent.Add(n.interval)))
+}
+
+// scheduleLocked agenda o flush do canal se ainda não houver um; n.mu deve estar travado.
+func (n *Notifier) scheduleLocked(id uint, b *batch, wait time.Duration) {
+	if b.timer != nil {
+		return
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	b.timer = time.AfterFunc(wait, func() { n.flush(id) })
+}
+
+func (n *Notifier) flush(id uint) {
+	n.mu.Lock()
+	b := n.batches[id]
+	lines, url := b.lines, b.url
+	b.lines, b.timer, b.lastSent = nil, nil, time.Now()
+	n.mu.Unlock()
+	if len(lines) == 0 {
+		return
+	}
+
+	retryAfter, err := n.post(context.Background(), url, Build(lines))
+	if retryAfter > 0 {
+		// limitado pelo servidor (429): devolve as linhas e tenta de novo depois
+		n.mu.Lock()
+		b.lines = append(lines, b.lines...)
+		if len(b.lines) > maxPending {
+			b.lines = b.lines[len(b.lines)-maxPending:]
+		}
+		if b.timer != nil {
+			b.timer.Stop()
+			b.timer = nil
+		}
+		n.scheduleLocked(id, b, retryAfter)
+		n.mu.Unlock()
+	}
+	msg := ""
+	if err != nil {
+		msg = err.Error()
+		log.Printf("chat channel %d send failed: %v", id, err)
+	}
+	if err := n.db.Model(&models.ChatChannel{}).Where("id = ?", id).Update("last_error", msg).Error; err != nil {
+		log.Printf("chat channel %d update error: %v", id, err)
+	}
+}
+
+// post envia a mensagem; em 429 devolve quanto esperar (Retry-After). As falhas viram
+// *outbound.Error (só o status): o erro de rede fica no log, não em last_error nem na resposta do teste.
+func (n *Notifier) post(ctx context.Context, url string, msg Message) (time.Duration, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		log.Printf("chat post failed: %v", err)
+		return 0, &outbound.Error{}
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 256))
+	if resp.StatusCode == http.StatusTooManyRequests {
+		wait := n.interval
+		if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && s > 0 {
+			wait = time.Duration(s) * time.Second
+		}
+		return wait, fmt.Errorf("rate limited, retrying in %s: %w", wait, &outbound.Error{Status: resp.StatusCode})
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return 0, &outbound.Error{Status: resp.StatusCode}
+	}
+	return 0, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"goTasks/internal/models"
+	"goTasks/internal/ws"
+)
+
+func TestNotifierBatchesWithinInterval(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&models.User{}, &models.NotificationPreference{}, &models.ChatChannel{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var got []Message
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var m Message
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			http.Error(w, "bad json", http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		got = append(got, m)
+		mu.Unlock()
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	user := models.User{Name: "Ana", Email: "ana@example.com"}
+	other := models.User{Name: "Bob", Email: "bob@example.com"}
+	db.Create(&user)
+	db.Create(&other)
+	p := models.DefaultNotificationPreference(user.ID)
+	p.Channels[models.NotificationComment] = []string{models.ChannelInApp, models.ChannelChat}
+	db.Create(&p)
+	ch := models.ChatChannel{UserID: user.ID, Name: "time", URL: srv.URL, Notifications: true, TaskEvents: []string{"task.updated"}, Active: true}
+	db.Create(&ch)
+
+	n := NewNotifier(db, "http://app", 150*time.Millisecond)
+	// o servidor de teste é local: fora do que o cliente de produção aceita
+	n.client = srv.Client()
+	if err := n.SendTest(context.Background(), ch); err != nil {
+		t.Fatal(err)
+	}
+	events := []ws.Event{
+		{Type: "task.updated", Payload: models.Task{ID: 1, Title: "A <b>", Status: models.StatusDoing}, To: []uint{user.ID}},
+		{Type: "task.updated", Payload: models.Task{ID: 2, Title: "B", Status: models.StatusDone}, To: []uint{user.ID}},
+		{Type: "task.created", Payload: models.Task{ID: 3, Title: "não assinado"}, To: []uint{user.ID}},
+		{Type: "task.updated", Payload: models.Task{ID: 4, Title: "de outro"}, To: []uint{other.ID}},
+		{Type: "notification.created", Payload: models.Notification{UserID: user.ID, TaskID: 2, Type: models.NotificationComment, Message: "2 novos comentários em B"}},
+		{Type: "notification.created", Payload: models.Notification{UserID: user.ID, TaskID: 2, Type: models.NotificationDueSoon, Message: "sem canal chat"}},
+	}
+	waitFor := func(want int) {
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			mu.Lock()
+			count := len(got)
+			mu.Unlock()
+			if count >= want {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	// o primeiro sai na hora; o que chega dentro do intervalo vai num único post
+	for i, ev := range events {
+		if err := n.handle(ev); err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			waitFor(2)
+		}
+	}
+	waitFor(3)
+	time.Sleep(200 * time.Millisecond) // nada além do esperado deve chegar
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 3 {
+		t.Fatalf("expected test message + 2 posts, got %d: %+v", len(got), got)
+	}
+	first, second := got[1], got[2]
+	if len(first.Attachments) != 1 || first.Text != "Tarefa atualizada: A &lt;b&gt; (doing)" || first.Attachments[0].TitleLink != "http://app/tasks?task=1" {
+		t.Fatalf("unexpected first post: %+v", first)
+	}
+	if second.Text != "2 atualizações no goTasks" || len(second.Attachments) != 2 || second.Attachments[1].Title != "2 novos comentários em B" {
+		t.Fatalf("expected the rest batched in one post, got %+v", second)
+	}
+}
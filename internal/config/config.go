@@ -11,6 +11,13 @@ type Config struct {
 	AnthropicKey string
 	AIModel      string
 	AILimitDaily int
+	AppURL       string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUser     string
+	SMTPPassword string
+	MailFrom     string
+	MailDir      string // sink de arquivos .eml quando não há SMTP (dev/testes)
 }
 
 func Load() Config {
@@ -34,6 +41,18 @@ func Load() Config {
 		model = "gpt-4o-mini"
 	}
 	limit := 100 // padrão diário
+	appURL := os.Getenv("APP_URL")
+	if appURL == "" {
+		appURL = "http://localhost:3001"
+	}
+	smtpPort := os.Getenv("SMTP_PORT")
+	if smtpPort == "" {
+		smtpPort = "587"
+	}
+	mailFrom := os.Getenv("MAIL_FROM")
+	if mailFrom == "" {
+		mailFrom = "goTasks <no-reply@gotasks.local>"
+	}
 	return Config{
 		Port:        port,
 		DatabaseURL: dbURL,
@@ -43,5 +62,12 @@ func Load() Config {
 		AnthropicKey: anth,
 		AIModel:      model,
 		AILimitDaily: limit,
+		AppURL:       appURL,
+		SMTPHost:     os.Getenv("SMTP_HOST"),
+		SMTPPort:     smtpPort,
+		SMTPUser:     os.Getenv("SMTP_USERNAME"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+		MailFrom:     mailFrom,
+		MailDir:      os.Getenv("MAIL_DIR"),
 	}
 }
\ No newline at end of file
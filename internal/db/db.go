@@ -12,10 +12,76 @@ func Connect(dsn string) (*gorm.DB, error) {
 }
 
 func AutoMigrate(db *gorm.DB) error {
-	return db.AutoMigrate(
+	if err := db.AutoMigrate(
+		&models.Organization{},
 		&models.User{},
+		&models.TaskSeries{},
+		&models.Project{},
+		&models.ProjectMember{},
 		&models.Task{},
+		&models.TaskRevision{},
+		&models.Setting{},
 		&models.Comment{},
 		&models.Notification{}, // novo: tabela de notificações
-	)
+		&models.NotificationPreference{},
+		&models.EmailDelivery{},
+		&models.Lease{},
+		&models.SchedulerState{},
+		&models.TaskReminder{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.ChatChannel{},
+		&models.Label{},
+		&models.ChecklistItem{},
+		&models.TaskDependency{},
+		&models.Workflow{},
+	); err != nil {
+		return err
+	}
+	// o nome único por dono valia para todas as etiquetas; agora só para as pessoais
+	if db.Migrator().HasIndex(&models.Label{}, "idx_labels_owner_name") {
+		if err := db.Migrator().DropIndex(&models.Label{}, "idx_labels_owner_name"); err != nil {
+			return err
+		}
+	}
+	// junções de responsáveis/observadores: a chave é (task_id, user_id); "minhas tarefas" busca por user_id
+	for _, table := range []string{models.TaskAssigneesTable, models.TaskWatchersTable} {
+		if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_" + table + "_user ON " + table + " (user_id)").Error; err != nil {
+			return err
+		}
+	}
+	if err := backfillOrgs(db); err != nil {
+		return err
+	}
+	// tarefas de antes do quadro: a ordem de criação vira a ordem manual
+	if err := db.Exec("UPDATE tasks SET position = id * 1024 WHERE position IS NULL").Error; err != nil {
+		return err
+	}
+	// tarefas de antes dos workflows: categoria pelo workflow padrão (todo/doing/done)
+	return db.Exec(`UPDATE tasks SET status_category = CASE status WHEN ? THEN ? WHEN ? THEN ? ELSE ? END
+		WHERE status_category IS NULL OR status_category = ''`,
+		models.StatusDone, models.CategoryDone, models.StatusDoing, models.CategoryInProgress, models.CategoryNotStarted).Error
+}
+
+// backfillOrgs leva os dados de antes das organizações para uma organização padrão.
+func backfillOrgs(db *gorm.DB) error {
+	var orphans int64
+	if err := db.Model(&models.User{}).Where("org_id = 0").Count(&orphans).Error; err != nil || orphans == 0 {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		org := models.Organization{Name: "Default"}
+		if err := tx.Create(&org).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.User{}).Where("org_id = 0").Update("org_id", org.ID).Error; err != nil {
+			return err
+		}
+		// tarefas e projetos seguem a organização do dono
+		if err := tx.Exec("UPDATE tasks SET org_id = (SELECT org_id FROM users WHERE users.id = tasks.owner_id) WHERE org_id = 0").Error; err != nil {
+			return err
+		}
+		return tx.Exec(`UPDATE projects SET org_id = COALESCE((SELECT MIN(u.org_id) FROM users u JOIN project_members m ON m.user_id = u.id
+			WHERE m.project_id = projects.id AND m.role = ?), ?) WHERE org_id = 0`, models.ProjectOwner, org.ID).Error
+	})
 }
\ No newline at end of file
@@ -0,0 +1,115 @@
+// Package deps guarda o grafo "bloqueada por / bloqueia" entre tarefas e impede ciclos.
+package deps
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"goTasks/internal/models"
+)
+
+var (
+	ErrSelf  = errors.New("a task cannot depend on itself")
+	ErrCycle = errors.New("dependency would create a cycle")
+)
+
+// MaxNodes limita o tamanho de um grafo devolvido de uma vez.
+const MaxNodes = 500
+
+type Graph struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Graph {
+	return &Graph{db: db}
+}
+
+// Add registra que taskID é bloqueada por blockerID. Recusa a aresta se blockerID
+// já depende, direta ou transitivamente, de taskID. Repetir uma aresta existente não é erro.
+// As inclusões de uma organização são serializadas: duas arestas que só juntas fecham um
+// ciclo não passam ao mesmo tempo pela verificação sem ver uma à outra.
+func (g *Graph) Add(taskID, blockerID uint) error {
+	if taskID == blockerID {
+		return ErrSelf
+	}
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		var org models.Organization
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+			Where("id = (?)", tx.Model(&models.Task{}).Select("org_id").Where("id = ?", taskID)).
+			Limit(1).Find(&org).Error; err != nil {
+			return err
+		}
+		upstream, _, err := walk(tx, blockerID, "task_id", "blocker_id", 0)
+		if err != nil {
+			return err
+		}
+		for _, id := range upstream {
+			if id == taskID {
+				return ErrCycle
+			}
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.TaskDependency{TaskID: taskID, BlockerID: blockerID}).Error
+	})
+}
+
+// Remove desfaz a aresta; devolve false se ela não existia.
+func (g *Graph) Remove(taskID, blockerID uint) (bool, error) {
+	res := g.db.Where("task_id = ? AND blocker_id = ?", taskID, blockerID).Delete(&models.TaskDependency{})
+	return res.RowsAffected > 0, res.Error
+}
+
+// Upstream devolve as tarefas de que id depende (transitivamente) e as arestas entre elas.
+func (g *Graph) Upstream(id uint) ([]uint, []models.TaskDependency, error) {
+	return walk(g.db, id, "task_id", "blocker_id", MaxNodes)
+}
+
+// Downstream devolve as tarefas que dependem (transitivamente) de id e as arestas entre elas.
+func (g *Graph) Downstream(id uint) ([]uint, []models.TaskDependency, error) {
+	return walk(g.db, id, "blocker_id", "task_id", MaxNodes)
+}
+
+// OpenBlockers lista os bloqueios diretos de id fora da categoria de conclusão.
+func (g *Graph) OpenBlockers(id uint) ([]models.Task, error) {
+	blockers := []models.Task{}
+	err := g.db.Select("id", "title", "status", "owner_id").
+		Where("id IN (?)", g.db.Model(&models.TaskDependency{}).Select("blocker_id").Where("task_id = ?", id)).
+		Where("status_category <> ?", models.CategoryDone).
+		Order("id").Find(&blockers).Error
+	return blockers, err
+}
+
+// walk percorre o grafo em largura a partir de id, indo da coluna from para a coluna to.
+// limit > 0 interrompe a busca ao atingir esse número de nós.
+func walk(db *gorm.DB, id uint, from, to string, limit int) ([]uint, []models.TaskDependency, error) {
+	var nodes []uint
+	var edges []models.TaskDependency
+	seen := map[uint]bool{id: true}
+	level := []uint{id}
+	for len(level) > 0 {
+		var found []models.TaskDependency
+		if err := db.Where(from+" IN ?", level).Order("task_id, blocker_id").Find(&found).Error; err != nil {
+			return nil, nil, err
+		}
+		level = nil
+		for _, e := range found {
+			edges = append(edges, e)
+			next := e.BlockerID
+			if to == "task_id" {
+				next = e.TaskID
+			}
+			if seen[next] {
+				continue
+			}
+			seen[next] = true
+			nodes = append(nodes, next)
+			level = append(level, next)
+			if limit > 0 && len(nodes) >= limit {
+				return nodes, edges, nil
+			}
+		}
+	}
+	return nodes, edges, nil
+}
@@ -0,0 +1,102 @@
+package deps
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"goTasks/internal/models"
+)
+
+// newGraph abre o banco com uma organização e as tarefas 1..tasks.
+func newGraph(t *testing.T, dsn string, conns, tasks int) (*Graph, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(conns)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&models.Organization{}, &models.Task{}, &models.TaskDependency{}); err != nil {
+		t.Fatal(err)
+	}
+	org := models.Organization{Name: "acme"}
+	if err := db.Create(&org).Error; err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= tasks; i++ {
+		if err := db.Create(&models.Task{ID: uint(i), Title: "t", OrgID: org.ID}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return New(db), db
+}
+
+func TestAddRejectsCycles(t *testing.T) {
+	g, _ := newGraph(t, ":memory:", 1, 4)
+	// 1 <- 2 <- 3 <- 4 (4 é bloqueada por 3, que é bloqueada por 2...)
+	for _, e := range [][2]uint{{2, 1}, {3, 2}, {4, 3}, {4, 3}} {
+		if err := g.Add(e[0], e[1]); err != nil {
+			t.Fatalf("add %v: %v", e, err)
+		}
+	}
+	if err := g.Add(1, 4); !errors.Is(err, ErrCycle) {
+		t.Fatalf("expected a transitive cycle to be rejected, got %v", err)
+	}
+	if err := g.Add(2, 2); !errors.Is(err, ErrSelf) {
+		t.Fatalf("expected self dependency to be rejected, got %v", err)
+	}
+	if err := g.Add(4, 1); err != nil {
+		t.Fatalf("a shortcut edge is not a cycle: %v", err)
+	}
+
+	up, edges, err := g.Upstream(4)
+	if err != nil || len(up) != 3 || len(edges) != 4 {
+		t.Fatalf("upstream of 4: %v %v %v", up, edges, err)
+	}
+	down, _, err := g.Downstream(2)
+	if err != nil || len(down) != 2 {
+		t.Fatalf("downstream of 2: %v %v", down, err)
+	}
+	if ok, _ := g.Remove(3, 2); !ok {
+		t.Fatal("expected edge to be removed")
+	}
+	if err := g.Add(1, 4); !errors.Is(err, ErrCycle) {
+		t.Fatalf("4 -> 1 still exists, got %v", err)
+	}
+}
+
+func TestConcurrentAddsCannotCloseACycle(t *testing.T) {
+	// banco em arquivo com várias conexões, para as inclusões concorrerem de verdade; o sqlite
+	// não tem FOR UPDATE, então _txlock=immediate faz o papel da trava da organização
+	dsn := "file:" + filepath.Join(t.TempDir(), "deps.db") + "?_busy_timeout=5000&_txlock=immediate"
+	const rounds = 20
+	g, db := newGraph(t, dsn, 4, 2*rounds)
+	for r := 0; r < rounds; r++ {
+		a, b := uint(2*r+1), uint(2*r+2)
+		errs := make([]error, 2)
+		var wg sync.WaitGroup
+		for i, e := range [][2]uint{{a, b}, {b, a}} {
+			wg.Add(1)
+			go func(i int, e [2]uint) {
+				defer wg.Done()
+				errs[i] = g.Add(e[0], e[1])
+			}(i, e)
+		}
+		wg.Wait()
+		// exatamente uma entra; a outra vê a primeira e vira ciclo
+		if (errs[0] == nil) == (errs[1] == nil) || !(errors.Is(errs[0], ErrCycle) || errors.Is(errs[1], ErrCycle)) {
+			t.Fatalf("round %d: expected one edge and one cycle, got %v and %v", r, errs[0], errs[1])
+		}
+		var edges int64
+		db.Model(&models.TaskDependency{}).Where("task_id IN ?", []uint{a, b}).Count(&edges)
+		if edges != 1 {
+			t.Fatalf("round %d: expected a single edge, got %d", r, edges)
+		}
+	}
+}
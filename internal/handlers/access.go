@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"goTasks/internal/models"
+	"goTasks/internal/policy"
+)
+
+// actor identifica o usuário da requisição para a camada de políticas.
+func actor(c *fiber.Ctx) policy.Actor {
+	uid, _ := c.Locals("userID").(uint)
+	orgID, _ := c.Locals("orgID").(uint)
+	userRole, _ := c.Locals("userRole").(string)
+	return policy.Actor{ID: uid, OrgID: orgID, Admin: userRole == models.RoleAdmin, OrgAdmin: userRole == models.RoleOrgAdmin}
+}
+
+// existingUsers confere que todos os ids são usuários da organização e os devolve.
+func existingUsers(db *gorm.DB, orgID uint, ids []uint) ([]models.User, bool) {
+	users := []models.User{}
+	if len(ids) == 0 {
+		return users, true
+	}
+	if err := db.Where("id IN ? AND org_id = ?", ids, orgID).Find(&users).Error; err != nil {
+		return nil, false
+	}
+	unique := map[uint]bool{}
+	for _, id := range ids {
+		unique[id] = true
+	}
+	return users, len(users) == len(unique)
+}
+
+// diffIDs devolve o que entrou em after e o que saiu de before.
+func diffIDs(before, after []uint) (added, removed []uint) {
+	for _, id := range after {
+		if !containsID(before, id) {
+			added = append(added, id)
+		}
+	}
+	for _, id := range before {
+		if !containsID(after, id) {
+			removed = append(removed, id)
+		}
+	}
+	return added, removed
+}
+
+func containsID(ids []uint, id uint) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+func userIDs(users []models.User) []uint {
+	ids := make([]uint, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+	return ids
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"goTasks/internal/notify"
+	"goTasks/internal/trash"
+)
+
+type AdminHandler struct {
+	db        *gorm.DB
+	scheduler *notify.Scheduler
+}
+
+func NewAdminHandler(db *gorm.DB, scheduler *notify.Scheduler) *AdminHandler {
+	return &AdminHandler{db: db, scheduler: scheduler}
+}
+
+// TrashSettings mostra por quantos dias as tarefas excluídas ficam na lixeira
+func (h *AdminHandler) TrashSettings(c *fiber.Ctx) error {
+	days, err := trash.Retention(h.db)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.JSON(fiber.Map{"retentionDays": days})
+}
+
+// UpdateTrashSettings muda a retenção; vale a partir da próxima limpeza
+func (h *AdminHandler) UpdateTrashSettings(c *fiber.Ctx) error {
+	var body struct {
+		RetentionDays int `json:"retentionDays"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid data"})
+	}
+	if err := trash.SetRetention(h.db, body.RetentionDays); err != nil {
+		if errors.Is(err, trash.ErrRetention) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.JSON(fiber.Map{"retentionDays": body.RetentionDays})
+}
+
+// SchedulerHealth mostra o estado desta réplica e quem detém a liderança do scheduler
+func (h *AdminHandler) SchedulerHealth(c *fiber.Ctx) error {
+	lease, err := h.scheduler.Lease()
+	if err != nil {
+		// ninguém assumiu ainda (ex.: API acabou de subir)
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"healthy": false,
+			"replica": h.scheduler.Status(),
+			"error":   "no leader elected",
+		})
+	}
+	healthy := lease.ExpiresAt.After(time.Now()) && lease.LastError == ""
+	status := fiber.StatusOK
+	if !healthy {
+		status = fiber.StatusServiceUnavailable
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"healthy": healthy,
+		"leader":  lease,
+		"replica": h.scheduler.Status(),
+	})
+}
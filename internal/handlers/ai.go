@@ -8,6 +8,7 @@ import (
 	"gorm.io/gorm"
 
 	"goTasks/internal/models"
+	"goTasks/internal/policy"
 )
 
 type AIClient interface {
@@ -36,7 +37,7 @@ func (h *AIHandler) SummarizeTask(c *fiber.Ctx) error {
 	if err := h.db.Preload("Owner").First(&task, "id = ?", id).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
 	}
-	if task.OwnerID != uid {
+	if role, err := policy.OnTask(h.db, actor(c), task); err != nil || role < policy.Viewer {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "not allowed"})
 	}
 
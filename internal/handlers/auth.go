@@ -10,15 +10,17 @@ import (
 
 	"goTasks/internal/auth"
 	"goTasks/internal/models"
+	"goTasks/internal/ws"
 )
 
 type AuthHandler struct {
 	db        *gorm.DB
 	jwtSecret string
+	hub       *ws.Hub
 }
 
-func NewAuthHandler(db *gorm.DB, secret string) *AuthHandler {
-	return &AuthHandler{db: db, jwtSecret: secret}
+func NewAuthHandler(db *gorm.DB, secret string, hub *ws.Hub) *AuthHandler {
+	return &AuthHandler{db: db, jwtSecret: secret, hub: hub}
 }
 
 func (h *AuthHandler) Register(c *fiber.Ctx) error {
@@ -26,11 +28,20 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 		Name     string `json:"name"`
 		Email    string `json:"email"`
 		Password string `json:"password"`
+		// Organization: nome da nova organização; quem se registra vira org_admin dela
+		Organization string `json:"organization"`
 	}
 	if err := c.BodyParser(&body); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid JSON"})
 	}
 	body.Email = strings.ToLower(strings.TrimSpace(body.Email))
+	body.Organization = strings.TrimSpace(body.Organization)
+	if body.Organization == "" {
+		body.Organization = body.Name
+	}
+	if len(body.Organization) > 100 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "organization must have up to 100 characters"})
+	}
 	if body.Name == "" || body.Email == "" || len(body.Password) < 6 {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid data"})
 	}
@@ -41,20 +52,32 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 	}
 
 	hash, _ := bcrypt.GenerateFromPassword([]byte(body.Password), bcrypt.DefaultCost)
-	user := models.User{Name: body.Name, Email: body.Email, PasswordHash: string(hash), Role: "user"}
-	if err := h.db.Create(&user).Error; err != nil {
+	user := models.User{Name: body.Name, Email: body.Email, PasswordHash: string(hash), Role: models.RoleOrgAdmin}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		org := models.Organization{Name: body.Organization}
+		if err := tx.Create(&org).Error; err != nil {
+			return err
+		}
+		user.OrgID = org.ID
+		return tx.Create(&user).Error
+	})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
 	}
+	return h.session(c, user)
+}
 
-	token, err := auth.CreateToken(user.ID, user.Role, h.jwtSecret)
+// session emite os tokens do usuário e devolve o corpo de login/registro
+func (h *AuthHandler) session(c *fiber.Ctx, user models.User) error {
+	token, err := auth.CreateToken(user.ID, user.Role, user.OrgID, user.TokenVersion, h.jwtSecret)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
 	}
-	refresh, err := auth.CreateRefreshToken(user.ID, user.Role, h.jwtSecret)
+	refresh, err := auth.CreateRefreshToken(user.ID, user.Role, user.OrgID, user.TokenVersion, h.jwtSecret)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
 	}
-	return c.JSON(fiber.Map{"token": token, "refreshToken": refresh, "user": fiber.Map{"id": user.ID, "name": user.Name, "email": user.Email, "role": user.Role}})
+	return c.JSON(fiber.Map{"token": token, "refreshToken": refresh, "user": fiber.Map{"id": user.ID, "name": user.Name, "email": user.Email, "role": user.Role, "orgId": user.OrgID}})
 }
 
 func (h *AuthHandler) Login(c *fiber.Ctx) error {
@@ -74,16 +97,7 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(body.Password)); err != nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid credentials"})
 	}
-
-	token, err := auth.CreateToken(user.ID, user.Role, h.jwtSecret)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
-	}
-	refresh, err := auth.CreateRefreshToken(user.ID, user.Role, h.jwtSecret)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
-	}
-	return c.JSON(fiber.Map{"token": token, "refreshToken": refresh, "user": fiber.Map{"id": user.ID, "name": user.Name, "email": user.Email, "role": user.Role}})
+	return h.session(c, user)
 }
 
 func (h *AuthHandler) Refresh(c *fiber.Ctx) error {
@@ -102,10 +116,29 @@ func (h *AuthHandler) Refresh(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid refresh"})
 	}
 	subF, _ := claims["sub"].(float64)
-	role, _ := claims["role"].(string)
-	token, err := auth.CreateToken(uint(subF), role, h.jwtSecret)
+	verF, _ := claims["ver"].(float64)
+	var user models.User
+	if err := h.db.Select("id", "role", "org_id", "token_version").First(&user, "id = ?", uint(subF)).Error; err != nil || user.TokenVersion != int(verF) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "session revoked"})
+	}
+	// papel e organização vêm do banco: mudanças feitas pelo org_admin valem no próximo refresh
+	token, err := auth.CreateToken(user.ID, user.Role, user.OrgID, user.TokenVersion, h.jwtSecret)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
 	}
 	return c.JSON(fiber.Map{"token": token})
+}
+
+// Logout revoga todos os tokens do usuário e derruba suas conexões em tempo real.
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	uid, ok := c.Locals("userID").(uint)
+	if !ok || uid == 0 {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	if err := h.db.Model(&models.User{}).Where("id = ?", uid).
+		UpdateColumn("token_version", gorm.Expr("token_version + 1")).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	h.hub.DisconnectUser(uid, "session revoked")
+	return c.SendStatus(fiber.StatusNoContent)
 }
\ No newline at end of file
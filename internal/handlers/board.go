@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"goTasks/internal/history"
+	"goTasks/internal/models"
+	"goTasks/internal/policy"
+	"goTasks/internal/ws"
+)
+
+// positionStep é o espaço entre tarefas ao final de uma coluna e depois de renumerar.
+const positionStep = 1024.0
+
+var errInvalidNeighbors = errors.New("afterId and beforeId must be tasks of the target column, in this order")
+
+// boardColumn é uma coluna do quadro: um status do projeto ou, sem projeto, das tarefas pessoais do dono.
+type boardColumn struct {
+	ProjectID *uint
+	OwnerID   uint
+	Status    models.TaskStatus
+}
+
+func columnOf(t models.Task) boardColumn {
+	return boardColumn{ProjectID: t.ProjectID, OwnerID: t.OwnerID, Status: t.Status}
+}
+
+func (col boardColumn) same(other boardColumn) bool {
+	if col.Status != other.Status || !sameID(col.ProjectID, other.ProjectID) {
+		return false
+	}
+	return col.ProjectID != nil || col.OwnerID == other.OwnerID
+}
+
+// tasks filtra as tarefas da coluna, menos a que está sendo movida.
+func (col boardColumn) tasks(db *gorm.DB, skipID uint) *gorm.DB {
+	q := db.Model(&models.Task{}).Where("status = ? AND id <> ?", col.Status, skipID)
+	if col.ProjectID != nil {
+		return q.Where("project_id = ?", *col.ProjectID)
+	}
+	return q.Where("project_id IS NULL AND owner_id = ?", col.OwnerID)
+}
+
+// endOfColumn devolve a posição de uma tarefa que entra no fim da coluna.
+func endOfColumn(db *gorm.DB, col boardColumn, skipID uint) (float64, error) {
+	var last []float64
+	if err := col.tasks(db, skipID).Order("position DESC").Limit(1).Pluck("position", &last).Error; err != nil {
+		return 0, err
+	}
+	if len(last) == 0 {
+		return positionStep, nil
+	}
+	return last[0] + positionStep, nil
+}
+
+// between devolve a posição entre prev e next (nil = ponta da coluna).
+// ok=false quando não cabe mais nada entre os dois e a coluna precisa ser renumerada.
+func between(prev, next *float64) (float64, bool) {
+	switch {
+	case prev == nil && next == nil:
+		return positionStep, true
+	case prev == nil:
+		return *next - positionStep, true
+	case next == nil:
+		return *prev + positionStep, true
+	}
+	mid := (*prev + *next) / 2
+	return mid, *prev < mid && mid < *next
+}
+
+// neighbors resolve as posições vizinhas: afterID fica logo acima, beforeID logo abaixo;
+// com só um deles, o outro vizinho é o que está hoje ao lado dele na coluna.
+func neighbors(db *gorm.DB, col boardColumn, taskID, afterID, beforeID uint) (prev, next *float64, err error) {
+	position := func(id uint) (*float64, error) {
+		var t models.Task
+		if err := col.tasks(db, taskID).Select("position").First(&t, "id = ?", id).Error; err != nil {
+			return nil, err
+		}
+		return &t.Position, nil
+	}
+	adjacent := func(q *gorm.DB, order string) (*float64, error) {
+		var found []float64
+		if err := q.Order(order).Limit(1).Pluck("position", &found).Error; err != nil || len(found) == 0 {
+			return nil, err
+		}
+		return &found[0], nil
+	}
+	if afterID != 0 {
+		if prev, err = position(afterID); err != nil {
+			return nil, nil, err
+		}
+	}
+	if beforeID != 0 {
+		if next, err = position(beforeID); err != nil {
+			return nil, nil, err
+		}
+	}
+	switch {
+	case afterID != 0 && beforeID == 0:
+		next, err = adjacent(col.tasks(db, taskID).Where("position > ?", *prev), "position ASC")
+	case beforeID != 0 && afterID == 0:
+		prev, err = adjacent(col.tasks(db, taskID).Where("position < ?", *next), "position DESC")
+	case afterID == 0 && beforeID == 0:
+		prev, err = adjacent(col.tasks(db, taskID), "position DESC")
+	}
+	return prev, next, err
+}
+
+// renumber espalha de novo as posições da coluna, mantendo a ordem atual.
+func renumber(tx *gorm.DB, col boardColumn, skipID uint) error {
+	var ids []uint
+	if err := col.tasks(tx, skipID).Order("position ASC, id ASC").Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	for i, id := range ids {
+		if err := tx.Model(&models.Task{}).Where("id = ?", id).UpdateColumn("position", float64(i+1)*positionStep).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Board devolve as tarefas agrupadas pelos status do workflow, na ordem manual.
+// Com projectId, o quadro do projeto; sem, as tarefas pessoais do usuário.
+func (h *TaskHandler) Board(c *fiber.Ctx) error {
+	uid, ok := c.Locals("userID").(uint)
+	if !ok || uid == 0 {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	col := boardColumn{OwnerID: uid}
+	if n := parseIntDefault(c.Query("projectId"), 0); n > 0 {
+		id := uint(n)
+		if role, err := policy.OnProject(h.db, actor(c), id); err != nil || role < policy.Viewer {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "project not found"})
+		}
+		col.ProjectID = &id
+	}
+	wf, err := workflowFor(h.db, col.ProjectID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	var tasks []models.Task
+	q := h.db.Preload("Owner").Preload("Labels").Preload("Assignees").Where("status IN ?", wf.Keys())
+	if col.ProjectID != nil {
+		q = q.Where("project_id = ?", *col.ProjectID)
+	} else {
+		q = q.Where("project_id IS NULL AND owner_id = ?", uid)
+	}
+	if err := q.Order("position ASC, id ASC").Find(&tasks).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	if err := loadProgress(h.db, tasks); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	byStatus := map[models.TaskStatus][]models.Task{}
+	for _, t := range tasks {
+		byStatus[t.Status] = append(byStatus[t.Status], t)
+	}
+	columns := make([]fiber.Map, 0, len(wf.Statuses))
+	for _, s := range wf.Statuses {
+		items := byStatus[s.Key]
+		if items == nil {
+			items = []models.Task{}
+		}
+		columns = append(columns, fiber.Map{"status": s.Key, "name": s.Name, "category": s.Category, "tasks": items})
+	}
+	return c.JSON(fiber.Map{"projectId": col.ProjectID, "columns": columns})
+}
+
+// Move leva a tarefa para outra posição e, se for o caso, para outra coluna (status).
+// Só a tarefa movida é gravada; a coluna é renumerada apenas quando acaba o espaço entre os vizinhos.
+func (h *TaskHandler) Move(c *fiber.Ctx) error {
+	var task models.Task
+	if err := h.db.First(&task, "id = ?", c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
+	}
+	uid, ok := c.Locals("userID").(uint)
+	if !ok || uid == 0 {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	role, err := policy.OnTask(h.db, actor(c), task)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	if role == policy.None {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
+	}
+	if role < policy.Editor {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "not allowed"})
+	}
+	if !ifMatch(c, task) {
+		return h.conflict(c, task.ID)
+	}
+	var body struct {
+		Status   *string `json:"status"`   // coluna de destino; omitido = a atual
+		AfterID  uint    `json:"afterId"`  // tarefa que fica logo acima
+		BeforeID uint    `json:"beforeId"` // tarefa que fica logo abaixo
+	}
+	if err := c.BodyParser(&body); err != nil || (body.AfterID != 0 && body.AfterID == body.BeforeID) ||
+		body.AfterID == task.ID || body.BeforeID == task.ID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid data"})
+	}
+	prevStatus, prevCategory := task.Status, task.StatusCategory
+	if body.Status != nil {
+		task.Status = models.TaskStatus(*body.Status)
+	}
+	wf, err := workflowFor(h.db, task.ProjectID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	if !wf.Allows(prevStatus, task.Status) {
+		return statusError(c, wf, prevStatus, task.Status, false)
+	}
+	task.StatusCategory = wf.Category(task.Status)
+	completed := task.StatusCategory == models.CategoryDone && prevCategory != models.CategoryDone
+	if completed {
+		if ok, err := h.guardBlockers(c, task.ID); !ok {
+			return err
+		}
+	}
+	col := columnOf(task)
+	var spawned *models.Task
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		before, _, err := history.Take(tx, task.ID)
+		if err != nil {
+			return err
+		}
+		prev, next, err := neighbors(tx, col, task.ID, body.AfterID, body.BeforeID)
+		if err != nil {
+			return err
+		}
+		position, ok := between(prev, next)
+		if !ok {
+			if err := renumber(tx, col, task.ID); err != nil {
+				return err
+			}
+			if prev, next, err = neighbors(tx, col, task.ID, body.AfterID, body.BeforeID); err != nil {
+				return err
+			}
+			if position, ok = between(prev, next); !ok {
+				// afterId abaixo de beforeId: a ordem pedida não existe
+				return errInvalidNeighbors
+			}
+		}
+		task.Position = position
+		res := tx.Model(&task).Where("version = ?", task.Version).Updates(map[string]interface{}{
+			"status":          task.Status,
+			"status_category": task.StatusCategory,
+			"position":        task.Position,
+			"version":         task.Version + 1,
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errVersionConflict
+		}
+		task.Version++
+		if err := history.Record(tx, task.ID, &before, history.By(uid)); err != nil {
+			return err
+		}
+		// concluir pelo quadro também gera a próxima ocorrência na mesma transação
+		if completed {
+			spawned, err = spawnNext(tx, task)
+			return err
+		}
+		return nil
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, errInvalidNeighbors) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": errInvalidNeighbors.Error()})
+	}
+	if errors.Is(err, errVersionConflict) {
+		return h.conflict(c, task.ID)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	// task.moved é o evento do quadro; task.updated leva a tarefa inteira a quem só assina esse (ex.: webhooks)
+	if err := h.db.Preload("Labels").Preload("Assignees").Preload("Watchers").First(&task, "id = ?", task.ID).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	audience := policy.Audience(h.db, task)
+	h.hub.Broadcast(ws.Event{Type: "task.moved", Payload: fiber.Map{"id": task.ID, "projectId": task.ProjectID, "from": prevStatus,
+		"status": task.Status, "statusCategory": task.StatusCategory, "position": task.Position, "version": task.Version}, To: audience, Org: task.OrgID})
+	h.hub.Broadcast(ws.Event{Type: "task.updated", Payload: task, To: audience, Org: task.OrgID})
+	if task.Status != prevStatus {
+		if task.ParentID != nil {
+			broadcastProgress(h.db, h.hub, *task.ParentID)
+		}
+		h.notifier.StatusChanged(task, prevStatus, uid)
+	}
+	if spawned != nil {
+		h.hub.Broadcast(ws.Event{Type: "task.created", Payload: *spawned, To: policy.Audience(h.db, *spawned), Org: spawned.OrgID})
+	}
+	c.Set(fiber.HeaderETag, taskETag(task))
+	return c.JSON(task)
+}
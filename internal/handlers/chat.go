@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"goTasks/internal/chat"
+	"goTasks/internal/models"
+	"goTasks/internal/outbound"
+	"goTasks/internal/policy"
+)
+
+type ChatChannelHandler struct {
+	db       *gorm.DB
+	notifier *chat.Notifier
+}
+
+func NewChatChannelHandler(db *gorm.DB, notifier *chat.Notifier) *ChatChannelHandler {
+	return &ChatChannelHandler{db: db, notifier: notifier}
+}
+
+// owned restringe aos canais pessoais do usuário e aos dos projetos de que ele é dono
+func (h *ChatChannelHandler) owned(c *fiber.Ctx) *gorm.DB {
+	uid, _ := c.Locals("userID").(uint)
+	owned := h.db.Model(&models.ProjectMember{}).Select("project_id").Where("user_id = ? AND role = ?", uid, models.ProjectOwner)
+	return h.db.Where("(project_id IS NULL AND user_id = ?) OR project_id IN (?)", uid, owned)
+}
+
+// channel carrega o canal se o usuário puder administrá-lo
+func (h *ChatChannelHandler) channel(c *fiber.Ctx) (models.ChatChannel, bool) {
+	var ch models.ChatChannel
+	err := h.owned(c).First(&ch, "id = ?", c.Params("id")).Error
+	return ch, err == nil
+}
+
+// List devolve os canais de chat do usuário e dos projetos que ele administra
+func (h *ChatChannelHandler) List(c *fiber.Ctx) error {
+	channels := []models.ChatChannel{}
+	if err := h.owned(c).Order("id").Find(&channels).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.JSON(channels)
+}
+
+// Create registra a URL de incoming webhook do Slack/Mattermost
+func (h *ChatChannelHandler) Create(c *fiber.Ctx) error {
+	uid, ok := c.Locals("userID").(uint)
+	if !ok || uid == 0 {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	var body struct {
+		Name          string   `json:"name"`
+		URL           string   `json:"url"`
+		Notifications *bool    `json:"notifications"`
+		TaskEvents    []string `json:"taskEvents"`
+		ProjectID     *uint    `json:"projectId"` // canal do projeto (só o dono do projeto)
+	}
+	if err := c.BodyParser(&body); err != nil || !validWebhookURL(body.URL) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid url"})
+	}
+	if msg := validateTaskEvents(body.TaskEvents); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+	}
+	if body.ProjectID != nil {
+		role, err := policy.OnProject(h.db, actor(c), *body.ProjectID)
+		if err != nil || role < policy.Owner {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "only project owners can add project channels"})
+		}
+		// canal de projeto só recebe eventos: notificações são pessoais
+		off := false
+		body.Notifications = &off
+	}
+	ch := models.ChatChannel{
+		UserID:        uid,
+		ProjectID:     body.ProjectID,
+		Name:          body.Name,
+		URL:           body.URL,
+		Notifications: body.Notifications == nil || *body.Notifications,
+		TaskEvents:    body.TaskEvents,
+		Active:        true,
+	}
+	if ch.Name == "" {
+		ch.Name = "chat"
+	}
+	if err := h.db.Create(&ch).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.Status(fiber.StatusCreated).JSON(ch)
+}
+
+// Update altera nome, url, o que é encaminhado ou ativa/desativa o canal
+func (h *ChatChannelHandler) Update(c *fiber.Ctx) error {
+	ch, ok := h.channel(c)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "channel not found"})
+	}
+	var body struct {
+		Name          *string   `json:"name"`
+		URL           *string   `json:"url"`
+		Notifications *bool     `json:"notifications"`
+		TaskEvents    *[]string `json:"taskEvents"`
+		Active        *bool     `json:"active"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid data"})
+	}
+	updates := map[string]interface{}{}
+	if body.Name != nil {
+		updates["name"] = *body.Name
+	}
+	if body.URL != nil {
+		if !validWebhookURL(*body.URL) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid url"})
+		}
+		updates["url"] = *body.URL
+		updates["last_error"] = ""
+	}
+	if body.Notifications != nil {
+		updates["notifications"] = *body.Notifications
+	}
+	if body.TaskEvents != nil {
+		if msg := validateTaskEvents(*body.TaskEvents); msg != "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+		}
+		ch.TaskEvents = *body.TaskEvents
+		updates["task_events"] = ch.TaskEvents
+	}
+	if body.Active != nil {
+		updates["active"] = *body.Active
+	}
+	if len(updates) > 0 {
+		if err := h.db.Model(&ch).Updates(updates).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+		}
+	}
+	if err := h.db.First(&ch, ch.ID).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.JSON(ch)
+}
+
+func (h *ChatChannelHandler) Delete(c *fiber.Ctx) error {
+	ch, ok := h.channel(c)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "channel not found"})
+	}
+	if err := h.db.Delete(&ch).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Test envia uma mensagem de teste na hora; na falha devolve só o status do serviço de chat
+func (h *ChatChannelHandler) Test(c *fiber.Ctx) error {
+	ch, ok := h.channel(c)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "channel not found"})
+	}
+	if err := h.notifier.SendTest(c.Context(), ch); err != nil {
+		failure := &outbound.Error{}
+		errors.As(err, &failure)
+		h.db.Model(&ch).Update("last_error", failure.Error())
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": "delivery failed", "status": failure.Status})
+	}
+	h.db.Model(&ch).Update("last_error", "")
+	return c.JSON(fiber.Map{"ok": true})
+}
+
+func validateTaskEvents(events []string) string {
+	for _, e := range events {
+		if !contains(chat.TaskEvents, e) {
+			return "unknown task event: " + e
+		}
+	}
+	return ""
+}
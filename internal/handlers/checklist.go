@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"goTasks/internal/models"
+	"goTasks/internal/policy"
+	"goTasks/internal/ws"
+)
+
+type ChecklistHandler struct {
+	db  *gorm.DB
+	hub *ws.Hub
+}
+
+func NewChecklistHandler(db *gorm.DB, hub *ws.Hub) *ChecklistHandler {
+	return &ChecklistHandler{db: db, hub: hub}
+}
+
+// task carrega a tarefa se o usuário tiver pelo menos o papel need nela
+func (h *ChecklistHandler) task(c *fiber.Ctx, need policy.Role) (models.Task, bool, error) {
+	var task models.Task
+	uid, ok := c.Locals("userID").(uint)
+	if !ok || uid == 0 {
+		return task, false, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	if err := h.db.First(&task, "id = ?", c.Params("id")).Error; err != nil {
+		return task, false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
+	}
+	role, err := policy.OnTask(h.db, actor(c), task)
+	if err != nil || role == policy.None {
+		return task, false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
+	}
+	if role < need {
+		return task, false, c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "not allowed"})
+	}
+	return task, true, nil
+}
+
+func (h *ChecklistHandler) items(taskID uint) ([]models.ChecklistItem, error) {
+	items := []models.ChecklistItem{}
+	err := h.db.Where("task_id = ?", taskID).Order("position ASC, id ASC").Find(&items).Error
+	return items, err
+}
+
+// changed envia o checklist atualizado e o novo progresso da tarefa
+func (h *ChecklistHandler) changed(task models.Task) {
+	items, err := h.items(task.ID)
+	if err != nil {
+		return
+	}
+	p := models.Progress{Total: len(items)}
+	for _, it := range items {
+		if it.Done {
+			p.Done++
+		}
+	}
+	h.hub.Broadcast(ws.Event{Type: "checklist.updated", Payload: fiber.Map{"taskId": task.ID, "items": items, "progress": p}, To: policy.Audience(h.db, task), Org: task.OrgID})
+}
+
+func (h *ChecklistHandler) List(c *fiber.Ctx) error {
+	task, ok, err := h.task(c, policy.Viewer)
+	if !ok {
+		return err
+	}
+	items, err := h.items(task.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.JSON(items)
+}
+
+// Create adiciona um item ao fim do checklist
+func (h *ChecklistHandler) Create(c *fiber.Ctx) error {
+	task, ok, err := h.task(c, policy.Editor)
+	if !ok {
+		return err
+	}
+	var body struct {
+		Text string `json:"text"`
+	}
+	if err := c.BodyParser(&body); err != nil || !validChecklistText(body.Text) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "text must have 1 to 500 characters"})
+	}
+	var stats struct {
+		Count int64
+		Last  int
+	}
+	if err := h.db.Model(&models.ChecklistItem{}).Select("COUNT(*) AS count, COALESCE(MAX(position), 0) AS last").
+		Where("task_id = ?", task.ID).Scan(&stats).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	if stats.Count >= models.MaxChecklistItems {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": "too many checklist items"})
+	}
+	item := models.ChecklistItem{TaskID: task.ID, Text: strings.TrimSpace(body.Text), Position: stats.Last + 1}
+	if err := h.db.Create(&item).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	h.changed(task)
+	return c.Status(fiber.StatusCreated).JSON(item)
+}
+
+// Update edita o texto ou marca/desmarca o item
+func (h *ChecklistHandler) Update(c *fiber.Ctx) error {
+	task, ok, err := h.task(c, policy.Editor)
+	if !ok {
+		return err
+	}
+	var item models.ChecklistItem
+	if err := h.db.First(&item, "id = ? AND task_id = ?", c.Params("itemId"), task.ID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "item not found"})
+	}
+	var body struct {
+		Text *string `json:"text"`
+		Done *bool   `json:"done"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid data"})
+	}
+	updates := map[string]interface{}{}
+	if body.Text != nil {
+		if !validChecklistText(*body.Text) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "text must have 1 to 500 characters"})
+		}
+		item.Text = strings.TrimSpace(*body.Text)
+		updates["text"] = item.Text
+	}
+	if body.Done != nil {
+		item.Done = *body.Done
+		updates["done"] = item.Done
+	}
+	if len(updates) > 0 {
+		if err := h.db.Model(&item).Updates(updates).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+		}
+		h.changed(task)
+	}
+	return c.JSON(item)
+}
+
+// Reorder recebe todos os ids do checklist na nova ordem
+func (h *ChecklistHandler) Reorder(c *fiber.Ctx) error {
+	task, ok, err := h.task(c, policy.Editor)
+	if !ok {
+		return err
+	}
+	var body struct {
+		IDs []uint `json:"ids"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid data"})
+	}
+	items, err := h.items(task.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	current := map[uint]bool{}
+	for _, it := range items {
+		current[it.ID] = true
+	}
+	if len(body.IDs) != len(items) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "ids must list every checklist item once"})
+	}
+	for _, id := range body.IDs {
+		if !current[id] {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "ids must list every checklist item once"})
+		}
+		delete(current, id)
+	}
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		for i, id := range body.IDs {
+			if err := tx.Model(&models.ChecklistItem{}).Where("id = ?", id).Update("position", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	h.changed(task)
+	if items, err = h.items(task.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.JSON(items)
+}
+
+func (h *ChecklistHandler) Delete(c *fiber.Ctx) error {
+	task, ok, err := h.task(c, policy.Editor)
+	if !ok {
+		return err
+	}
+	res := h.db.Where("id = ? AND task_id = ?", c.Params("itemId"), task.ID).Delete(&models.ChecklistItem{})
+	if res.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	if res.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "item not found"})
+	}
+	h.changed(task)
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func validChecklistText(s string) bool {
+	s = strings.TrimSpace(s)
+	return s != "" && len(s) <= 500
+}
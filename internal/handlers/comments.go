@@ -5,22 +5,32 @@ import (
 	"gorm.io/gorm"
 
 	"goTasks/internal/models"
+	"goTasks/internal/policy"
+	"goTasks/internal/notify"
 	"goTasks/internal/ws"
 )
 
 type CommentHandler struct {
-	db  *gorm.DB
-	hub *ws.Hub
+	db       *gorm.DB
+	hub      *ws.Hub
+	notifier *notify.Service
 }
 
-func NewCommentHandler(db *gorm.DB, hub *ws.Hub) *CommentHandler {
-	return &CommentHandler{db: db, hub: hub}
+func NewCommentHandler(db *gorm.DB, hub *ws.Hub, notifier *notify.Service) *CommentHandler {
+	return &CommentHandler{db: db, hub: hub, notifier: notifier}
 }
 
 func (h *CommentHandler) ListByTask(c *fiber.Ctx) error {
 	taskID := c.Params("id")
+	var task models.Task
+	if err := h.db.Select("id", "owner_id", "org_id", "project_id").First(&task, "id = ?", taskID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
+	}
+	if role, err := policy.OnTask(h.db, actor(c), task); err != nil || role < policy.Viewer {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
+	}
 	var comments []models.Comment
-	if err := h.db.Where("task_id = ?", taskID).Order("created_at ASC").Find(&comments).Error; err != nil {
+	if err := h.db.Where("task_id = ?", task.ID).Order("created_at ASC").Find(&comments).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
 	}
 	return c.JSON(comments)
@@ -35,14 +45,51 @@ func (h *CommentHandler) CreateOnTask(c *fiber.Ctx) error {
 	if err := c.BodyParser(&body); err != nil || body.Content == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid data"})
 	}
-	comment := models.Comment{TaskID: parseUint(taskID), UserID: userID, Content: body.Content}
+	var task models.Task
+	if err := h.db.First(&task, "id = ?", taskID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
+	}
+	if role, err := policy.OnTask(h.db, actor(c), task); err != nil || role < policy.Viewer {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
+	}
+	var author models.User
+	if err := h.db.First(&author, "id = ?", userID).Error; err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	comment := models.Comment{TaskID: task.ID, UserID: userID, Content: body.Content}
 	if err := h.db.Create(&comment).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
 	}
-	h.hub.Broadcast(ws.Event{Type: "comment.created", Payload: comment})
+	h.hub.Broadcast(ws.Event{Type: "comment.created", Payload: comment, To: policy.Audience(h.db, task, userID), Org: task.OrgID})
+	h.notifier.CommentCreated(task, comment, author)
 	return c.Status(fiber.StatusCreated).JSON(comment)
 }
 
+// Delete manda o comentário para a lixeira (autor ou dono da tarefa); sai de vez após a retenção
+func (h *CommentHandler) Delete(c *fiber.Ctx) error {
+	a := actor(c)
+	var task models.Task
+	if err := h.db.Select("id", "owner_id", "org_id", "project_id").First(&task, "id = ?", c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
+	}
+	role, err := policy.OnTask(h.db, a, task)
+	if err != nil || role < policy.Viewer {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
+	}
+	var comment models.Comment
+	if err := h.db.First(&comment, "id = ? AND task_id = ?", c.Params("commentId"), task.ID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "comment not found"})
+	}
+	if comment.UserID != a.ID && role < policy.Owner {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "not allowed"})
+	}
+	if err := h.db.Delete(&comment).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	h.hub.Broadcast(ws.Event{Type: "comment.deleted", Payload: fiber.Map{"id": comment.ID, "taskId": task.ID}, To: policy.Audience(h.db, task), Org: task.OrgID})
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
 func parseUint(s string) uint {
 	var out uint
 	for i := 0; i < len(s); i++ {
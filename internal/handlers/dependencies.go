@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"goTasks/internal/deps"
+	"goTasks/internal/models"
+	"goTasks/internal/policy"
+	"goTasks/internal/ws"
+)
+
+type DependencyHandler struct {
+	db    *gorm.DB
+	hub   *ws.Hub
+	graph *deps.Graph
+}
+
+func NewDependencyHandler(db *gorm.DB, hub *ws.Hub) *DependencyHandler {
+	return &DependencyHandler{db: db, hub: hub, graph: deps.New(db)}
+}
+
+// task carrega a tarefa id se o usuário tiver pelo menos o papel need nela
+func (h *DependencyHandler) task(c *fiber.Ctx, id interface{}, need policy.Role) (models.Task, bool) {
+	var task models.Task
+	if err := h.db.First(&task, "id = ?", id).Error; err != nil {
+		return task, false
+	}
+	role, err := policy.OnTask(h.db, actor(c), task)
+	return task, err == nil && role >= need
+}
+
+// List devolve o grafo acima (de que a tarefa depende) e abaixo (o que ela bloqueia)
+func (h *DependencyHandler) List(c *fiber.Ctx) error {
+	task, ok := h.task(c, c.Params("id"), policy.Viewer)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
+	}
+	upIDs, upEdges, err := h.graph.Upstream(task.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	downIDs, downEdges, err := h.graph.Downstream(task.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	upstream, err := h.subgraph(c, task.ID, upIDs, upEdges)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	downstream, err := h.subgraph(c, task.ID, downIDs, downEdges)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.JSON(fiber.Map{"taskId": task.ID, "upstream": upstream, "downstream": downstream})
+}
+
+// subgraph monta nós e arestas, sem as tarefas que o usuário não pode ver
+func (h *DependencyHandler) subgraph(c *fiber.Ctx, root uint, ids []uint, edges []models.TaskDependency) (fiber.Map, error) {
+	nodes := []models.Task{}
+	if len(ids) > 0 {
+		qry := h.db.Select("id", "title", "status", "owner_id").Where("id IN ?", ids)
+		if a := actor(c); !a.Admin {
+			qry = qry.Where("id IN (?)", policy.VisibleTaskIDs(h.db, a))
+		}
+		if err := qry.Order("id").Find(&nodes).Error; err != nil {
+			return nil, err
+		}
+	}
+	visible := map[uint]bool{root: true}
+	for _, n := range nodes {
+		visible[n.ID] = true
+	}
+	kept := []models.TaskDependency{}
+	for _, e := range edges {
+		if visible[e.TaskID] && visible[e.BlockerID] {
+			kept = append(kept, e)
+		}
+	}
+	out := make([]fiber.Map, 0, len(nodes))
+	for _, n := range nodes {
+		out = append(out, fiber.Map{"id": n.ID, "title": n.Title, "status": n.Status})
+	}
+	return fiber.Map{"nodes": out, "edges": kept}, nil
+}
+
+// Add marca a tarefa como bloqueada por {"blockerId": n}
+func (h *DependencyHandler) Add(c *fiber.Ctx) error {
+	task, ok := h.task(c, c.Params("id"), policy.Editor)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
+	}
+	var body struct {
+		BlockerID uint `json:"blockerId"`
+	}
+	if err := c.BodyParser(&body); err != nil || body.BlockerID == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "blockerId is required"})
+	}
+	blocker, ok := h.task(c, body.BlockerID, policy.Viewer)
+	if !ok || blocker.OrgID != task.OrgID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid blocker"})
+	}
+	err := h.graph.Add(task.ID, blocker.ID)
+	if errors.Is(err, deps.ErrSelf) || errors.Is(err, deps.ErrCycle) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	dep := models.TaskDependency{TaskID: task.ID, BlockerID: blocker.ID}
+	h.hub.Broadcast(ws.Event{Type: "dependency.added", Payload: dep, To: append(policy.Audience(h.db, task), policy.Audience(h.db, blocker)...), Org: task.OrgID})
+	return c.Status(fiber.StatusCreated).JSON(dep)
+}
+
+func (h *DependencyHandler) Remove(c *fiber.Ctx) error {
+	task, ok := h.task(c, c.Params("id"), policy.Editor)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
+	}
+	blockerID, err := c.ParamsInt("blockerId")
+	if err != nil || blockerID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid blocker"})
+	}
+	removed, err := h.graph.Remove(task.ID, uint(blockerID))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	if !removed {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "dependency not found"})
+	}
+	h.hub.Broadcast(ws.Event{Type: "dependency.removed", Payload: models.TaskDependency{TaskID: task.ID, BlockerID: uint(blockerID)}, To: policy.Audience(h.db, task), Org: task.OrgID})
+	return c.SendStatus(fiber.StatusNoContent)
+}
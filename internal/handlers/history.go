@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"goTasks/internal/history"
+	"goTasks/internal/models"
+	"goTasks/internal/policy"
+)
+
+// History devolve as revisões da tarefa, da mais recente para a mais antiga.
+func (h *TaskHandler) History(c *fiber.Ctx) error {
+	var task models.Task
+	if err := h.db.Select("id", "owner_id", "org_id", "project_id").First(&task, "id = ?", c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
+	}
+	if role, err := policy.OnTask(h.db, actor(c), task); err != nil || role < policy.Viewer {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
+	}
+	page := parseIntDefault(c.Query("page"), 1)
+	size := parseIntDefault(c.Query("size"), 50)
+	if size > 100 {
+		size = 100
+	}
+	if page < 1 {
+		page = 1
+	}
+	revisions := []models.TaskRevision{}
+	if err := h.db.Preload("Actor").Where("task_id = ?", task.ID).Order("created_at DESC, id DESC").
+		Limit(size).Offset((page - 1) * size).Find(&revisions).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.JSON(fiber.Map{"items": revisions, "page": page, "size": size, "count": len(revisions)})
+}
+
+// Restore devolve a tarefa ao estado de uma revisão. Vale como uma edição comum:
+// mesmos papéis, workflow e validações do PATCH, e gera uma nova revisão.
+func (h *TaskHandler) Restore(c *fiber.Ctx) error {
+	var task models.Task
+	if err := h.db.First(&task, "id = ?", c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
+	}
+	uid, ok := c.Locals("userID").(uint)
+	if !ok || uid == 0 {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	role, err := policy.OnTask(h.db, actor(c), task)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	if role == policy.None {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
+	}
+	if role < policy.Editor {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "not allowed"})
+	}
+	var rev models.TaskRevision
+	if err := h.db.First(&rev, "id = ? AND task_id = ?", c.Params("revisionId"), task.ID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "revision not found"})
+	}
+	current, _, err := history.Take(h.db, task.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	meta := history.By(uid)
+	meta.RestoredFrom = &rev.ID
+	return h.patch(c, task, role, restorePatch(current, rev.State), meta)
+}
+
+// restorePatch monta a edição que leva current de volta a target, só com os campos diferentes
+// (um editor consegue restaurar o texto sem esbarrar nos campos que só o dono muda).
+func restorePatch(current, target models.TaskSnapshot) taskPatch {
+	var p taskPatch
+	zero := uint(0)
+	orZero := func(id *uint) *uint {
+		if id == nil {
+			return &zero
+		}
+		return id
+	}
+	for _, ch := range history.Diff(current, target) {
+		switch ch.Field {
+		case "title":
+			p.Title = &target.Title
+		case "description":
+			p.Description = &target.Description
+		case "status":
+			status := string(target.Status)
+			p.Status = &status
+		case "priority":
+			priority := target.Priority.String()
+			p.Priority = &priority
+		case "dueDate":
+			p.DueDate, p.clearDue = target.DueDate, target.DueDate == nil
+		case "ownerId":
+			p.OwnerID = &target.OwnerID
+		case "projectId":
+			p.ProjectID = orZero(target.ProjectID)
+		case "parentId":
+			p.ParentID = orZero(target.ParentID)
+		case "labelIds":
+			p.LabelIDs = &target.LabelIDs
+		case "assigneeIds":
+			p.AssigneeIDs = &target.AssigneeIDs
+		case "watcherIds":
+			p.WatcherIDs = &target.WatcherIDs
+		}
+	}
+	return p
+}
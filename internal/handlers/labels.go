@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"goTasks/internal/models"
+	"goTasks/internal/policy"
+)
+
+var labelColorRe = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+type LabelHandler struct {
+	db *gorm.DB
+}
+
+func NewLabelHandler(db *gorm.DB) *LabelHandler {
+	return &LabelHandler{db: db}
+}
+
+// label carrega a etiqueta se o usuário puder geri-la: a pessoal dele ou a de um projeto em que é editor
+func (h *LabelHandler) label(c *fiber.Ctx) (models.Label, bool) {
+	var l models.Label
+	if err := h.db.First(&l, "id = ?", c.Params("id")).Error; err != nil {
+		return l, false
+	}
+	a := actor(c)
+	if l.ProjectID == nil {
+		return l, l.OwnerID == a.ID
+	}
+	role, err := policy.OnProject(h.db, a, *l.ProjectID)
+	return l, err == nil && role >= policy.Editor
+}
+
+// nameTaken indica se o dono (ou o projeto, na etiqueta de projeto) já tem outra etiqueta com esse nome
+func (h *LabelHandler) nameTaken(l models.Label) bool {
+	var count int64
+	qry := h.db.Model(&models.Label{}).Where("name = ? AND id <> ?", l.Name, l.ID)
+	if l.ProjectID != nil {
+		qry = qry.Where("project_id = ?", *l.ProjectID)
+	} else {
+		qry = qry.Where("owner_id = ? AND project_id IS NULL", l.OwnerID)
+	}
+	qry.Count(&count)
+	return count > 0
+}
+
+// List devolve as etiquetas pessoais do usuário e as dos projetos que ele vê, em ordem alfabética;
+// projectId=N fica só com as do projeto
+func (h *LabelHandler) List(c *fiber.Ctx) error {
+	qry := h.db.Where("id IN (?)", visibleLabelIDs(h.db, actor(c)))
+	if project := c.Query("projectId"); project != "" {
+		qry = qry.Where("project_id = ?", project)
+	}
+	labels := []models.Label{}
+	if err := qry.Order("name").Find(&labels).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.JSON(labels)
+}
+
+func (h *LabelHandler) Create(c *fiber.Ctx) error {
+	uid, ok := c.Locals("userID").(uint)
+	if !ok || uid == 0 {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	var body struct {
+		Name      string `json:"name"`
+		Color     string `json:"color"`
+		ProjectID *uint  `json:"projectId"` // etiqueta do projeto; exige papel de editor nele
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid data"})
+	}
+	if body.ProjectID != nil {
+		role, err := policy.OnProject(h.db, actor(c), *body.ProjectID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+		}
+		if role == policy.None {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "project not found"})
+		}
+		if role < policy.Editor {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "not allowed"})
+		}
+	}
+	l := models.Label{OwnerID: uid, ProjectID: body.ProjectID, Name: strings.TrimSpace(body.Name), Color: body.Color}
+	if l.Color == "" {
+		l.Color = models.DefaultLabelColor
+	}
+	if msg := validateLabel(l); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+	}
+	if h.nameTaken(l) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "label already exists"})
+	}
+	if err := h.db.Create(&l).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.Status(fiber.StatusCreated).JSON(l)
+}
+
+// Update renomeia ou troca a cor da etiqueta
+func (h *LabelHandler) Update(c *fiber.Ctx) error {
+	l, ok := h.label(c)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "label not found"})
+	}
+	var body struct {
+		Name  *string `json:"name"`
+		Color *string `json:"color"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid data"})
+	}
+	if body.Name != nil {
+		l.Name = strings.TrimSpace(*body.Name)
+	}
+	if body.Color != nil {
+		l.Color = *body.Color
+	}
+	if msg := validateLabel(l); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+	}
+	if h.nameTaken(l) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "label already exists"})
+	}
+	if err := h.db.Model(&l).Updates(map[string]interface{}{"name": l.Name, "color": l.Color}).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.JSON(l)
+}
+
+// Delete remove a etiqueta e a tira das tarefas
+func (h *LabelHandler) Delete(c *fiber.Ctx) error {
+	l, ok := h.label(c)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "label not found"})
+	}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM task_labels WHERE label_id = ?", l.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&l).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func validateLabel(l models.Label) string {
+	if l.Name == "" || len(l.Name) > 50 {
+		return "name must have 1 to 50 characters"
+	}
+	if !labelColorRe.MatchString(l.Color) {
+		return "color must be #rrggbb"
+	}
+	return ""
+}
+
+var errInvalidLabels = errors.New("labels must exist and belong to the task owner or project")
+
+// taskLabels carrega as etiquetas pedidas, que precisam ser todas pessoais do dono da tarefa
+// ou do projeto dela.
+func taskLabels(db *gorm.DB, ownerID uint, projectID *uint, ids []uint) ([]models.Label, error) {
+	labels := []models.Label{}
+	if len(ids) == 0 {
+		return labels, nil
+	}
+	fits := db.Where("owner_id = ? AND project_id IS NULL", ownerID)
+	if projectID != nil {
+		fits = fits.Or("project_id = ?", *projectID)
+	}
+	if err := db.Where("id IN ?", ids).Where(fits).Find(&labels).Error; err != nil {
+		return nil, err
+	}
+	unique := map[uint]bool{}
+	for _, id := range ids {
+		unique[id] = true
+	}
+	if len(labels) != len(unique) {
+		return nil, errInvalidLabels
+	}
+	return labels, nil
+}
+
+// visibleLabelIDs é a subconsulta das etiquetas que o ator vê: as pessoais dele e as dos
+// projetos que ele vê (todos os da organização, para o org_admin; todos, para o admin).
+func visibleLabelIDs(db *gorm.DB, a policy.Actor) *gorm.DB {
+	var projects *gorm.DB
+	switch {
+	case a.Admin:
+		projects = db.Model(&models.Project{}).Select("id")
+	case a.OrgAdmin:
+		projects = db.Model(&models.Project{}).Select("id").Where("org_id = ?", a.OrgID)
+	default:
+		projects = db.Model(&models.ProjectMember{}).Select("project_id").Where("user_id = ?", a.ID)
+	}
+	return db.Model(&models.Label{}).Select("id").
+		Where(db.Where("owner_id = ? AND project_id IS NULL", a.ID).Or("project_id IN (?)", projects))
+}
+
+// labelNames separa "a, b,,c" em nomes de etiqueta
+func labelNames(raw string) []string {
+	var names []string
+	for _, n := range strings.Split(raw, ",") {
+		if n = strings.TrimSpace(n); n != "" {
+			names = append(names, n)
+		}
+	}
+	return names
+}
@@ -2,22 +2,26 @@ package handlers
 
 import (
 	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 
 	"goTasks/internal/models"
+	"goTasks/internal/ws"
 )
 
 type NotificationsHandler struct {
-	DB *gorm.DB
+	DB  *gorm.DB
+	hub *ws.Hub
 }
 
-func NewNotificationsHandler(db *gorm.DB) *NotificationsHandler {
-	return &NotificationsHandler{DB: db}
+func NewNotificationsHandler(db *gorm.DB, hub *ws.Hub) *NotificationsHandler {
+	return &NotificationsHandler{DB: db, hub: hub}
 }
 
-// List lista notificações do usuário autenticado, com opção de filtrar não lidas (?unread=true)
+// List lista notificações do usuário autenticado com paginação por cursor (?cursor=&limit=)
+// e filtros ?unread=true, ?type= e ?taskId=
 func (h *NotificationsHandler) List(c *fiber.Ctx) error {
 	uidVal := c.Locals("userID")
 	uid, ok := uidVal.(uint)
@@ -25,17 +29,59 @@ func (h *NotificationsHandler) List(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
 	}
 
+	limit := parseIntDefault(c.Query("limit"), 20)
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
 	var notifications []models.Notification
-	tx := h.DB.Where("user_id = ?", uid).Order("created_at DESC").Limit(50)
+	tx := h.DB.Where("user_id = ?", uid).Order("id DESC").Limit(limit + 1)
 
+	// cursor = id da última notificação da página anterior (opaco para o cliente)
+	if cur := c.Query("cursor"); cur != "" {
+		id, err := strconv.ParseUint(cur, 10, 64)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid cursor"})
+		}
+		tx = tx.Where("id < ?", id)
+	}
 	if c.Query("unread") == "true" {
 		tx = tx.Where("read = ?", false)
 	}
+	if typ := c.Query("type"); typ != "" {
+		tx = tx.Where("type = ?", typ)
+	}
+	if taskID := c.Query("taskId"); taskID != "" {
+		tx = tx.Where("task_id = ?", taskID)
+	}
 
 	if err := tx.Find(&notifications).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
 	}
-	return c.JSON(notifications)
+
+	var next *string
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+		cur := strconv.FormatUint(uint64(notifications[limit-1].ID), 10)
+		next = &cur
+	}
+	return c.JSON(fiber.Map{"items": notifications, "nextCursor": next})
+}
+
+// UnreadCount devolve quantas notificações não lidas o usuário tem
+func (h *NotificationsHandler) UnreadCount(c *fiber.Ctx) error {
+	uid, ok := c.Locals("userID").(uint)
+	if !ok || uid == 0 {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	n, err := h.unread(uid)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+	return c.JSON(fiber.Map{"count": n})
 }
 
 // MarkRead marca uma notificação como lida se pertencer ao usuário autenticado
@@ -61,9 +107,87 @@ func (h *NotificationsHandler) MarkRead(c *fiber.Ctx) error {
 	}
 
 	n.Read = true
-	if err := h.DB.Save(&n).Error; err != nil {
+	if err := h.DB.Model(&n).Updates(map[string]interface{}{"read": true, "dedupe_key": models.ReleaseUnreadKey()}).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "update failed"})
 	}
 
+	h.publishRead(uid, fiber.Map{"ids": []uint{n.ID}})
 	return c.JSON(fiber.Map{"ok": true})
+}
+
+// ReadAll marca como lidas todas as notificações do usuário (opcionalmente só as criadas até ?before=RFC3339)
+func (h *NotificationsHandler) ReadAll(c *fiber.Ctx) error {
+	uid, ok := c.Locals("userID").(uint)
+	if !ok || uid == 0 {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	var body struct {
+		Before *time.Time `json:"before"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid data"})
+		}
+	}
+	if b := c.Query("before"); b != "" {
+		t, err := time.Parse(time.RFC3339, b)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid before"})
+		}
+		body.Before = &t
+	}
+
+	tx := h.DB.Model(&models.Notification{}).Where("user_id = ? AND read = ?", uid, false)
+	if body.Before != nil {
+		tx = tx.Where("created_at <= ?", *body.Before)
+	}
+	res := tx.Updates(map[string]interface{}{"read": true, "dedupe_key": models.ReleaseUnreadKey()})
+	if res.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "update failed"})
+	}
+
+	payload := fiber.Map{"all": true}
+	if body.Before != nil {
+		payload["before"] = body.Before
+	}
+	h.publishRead(uid, payload)
+	return c.JSON(fiber.Map{"updated": res.RowsAffected})
+}
+
+// Dismiss apaga uma notificação do usuário
+func (h *NotificationsHandler) Dismiss(c *fiber.Ctx) error {
+	uid, ok := c.Locals("userID").(uint)
+	if !ok || uid == 0 {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+	res := h.DB.Where("id = ? AND user_id = ?", id, uid).Delete(&models.Notification{})
+	if res.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+	if res.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "not found"})
+	}
+	unread, _ := h.unread(uid)
+	h.hub.Broadcast(ws.Event{Type: "notification.deleted", Payload: fiber.Map{"id": id, "unreadCount": unread}, To: []uint{uid}})
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// publishRead sincroniza o sino entre abas/dispositivos do mesmo usuário
+func (h *NotificationsHandler) publishRead(uid uint, payload fiber.Map) {
+	unread, err := h.unread(uid)
+	if err == nil {
+		payload["unreadCount"] = unread
+	}
+	h.hub.Broadcast(ws.Event{Type: "notification.read", Payload: payload, To: []uint{uid}})
+}
+
+func (h *NotificationsHandler) unread(uid uint) (int64, error) {
+	var n int64
+	err := h.DB.Model(&models.Notification{}).Where("user_id = ? AND read = ?", uid, false).Count(&n).Error
+	return n, err
 }
\ No newline at end of file
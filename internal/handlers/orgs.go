@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"goTasks/internal/models"
+	"goTasks/internal/ws"
+)
+
+var errLastOrgAdmin = errors.New("an organization needs at least one org_admin")
+
+type OrgHandler struct {
+	db  *gorm.DB
+	hub *ws.Hub
+}
+
+func NewOrgHandler(db *gorm.DB, hub *ws.Hub) *OrgHandler {
+	return &OrgHandler{db: db, hub: hub}
+}
+
+// Get devolve a organização do usuário
+func (h *OrgHandler) Get(c *fiber.Ctx) error {
+	var org models.Organization
+	if err := h.db.First(&org, "id = ?", actor(c).OrgID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "organization not found"})
+	}
+	return c.JSON(org)
+}
+
+// Update renomeia a organização (org_admin)
+func (h *OrgHandler) Update(c *fiber.Ctx) error {
+	a := actor(c)
+	if !a.OrgAdmin && !a.Admin {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "not allowed"})
+	}
+	var body struct {
+		Name string `json:"name"`
+	}
+	if err := c.BodyParser(&body); err != nil || !validProjectName(body.Name) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "name must have 1 to 100 characters"})
+	}
+	var org models.Organization
+	if err := h.db.First(&org, "id = ?", a.OrgID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "organization not found"})
+	}
+	org.Name = strings.TrimSpace(body.Name)
+	if err := h.db.Model(&org).Update("name", org.Name).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.JSON(org)
+}
+
+// Users lista os usuários da organização
+func (h *OrgHandler) Users(c *fiber.Ctx) error {
+	users := []models.User{}
+	if err := h.db.Where("org_id = ?", actor(c).OrgID).Order("name, id").Find(&users).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.JSON(users)
+}
+
+// AddUser cria uma conta na organização (org_admin)
+func (h *OrgHandler) AddUser(c *fiber.Ctx) error {
+	a := actor(c)
+	if !a.OrgAdmin && !a.Admin {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "not allowed"})
+	}
+	user, status, msg := createOrgUser(c, h.db, a.OrgID)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"error": msg})
+	}
+	return c.Status(fiber.StatusCreated).JSON(user)
+}
+
+// UpdateUser troca o papel de um usuário da organização (user ou org_admin)
+func (h *OrgHandler) UpdateUser(c *fiber.Ctx) error {
+	a := actor(c)
+	if !a.OrgAdmin && !a.Admin {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "not allowed"})
+	}
+	var body struct {
+		Role string `json:"role"`
+	}
+	if err := c.BodyParser(&body); err != nil || !contains(models.OrgRoles, body.Role) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "role must be user or org_admin"})
+	}
+	var user models.User
+	if err := h.db.First(&user, "id = ? AND org_id = ?", c.Params("id"), a.OrgID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "user not found"})
+	}
+	if user.Role == models.RoleAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "not allowed"})
+	}
+	if user.Role == body.Role {
+		return c.JSON(user)
+	}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		// trava a organização: duas trocas de papel simultâneas não deixam a org sem org_admin
+		var org models.Organization
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&org, "id = ?", a.OrgID).Error; err != nil {
+			return err
+		}
+		if user.Role == models.RoleOrgAdmin {
+			var admins int64
+			if err := tx.Model(&models.User{}).Where("org_id = ? AND role = ?", a.OrgID, models.RoleOrgAdmin).Count(&admins).Error; err != nil {
+				return err
+			}
+			if admins <= 1 {
+				return errLastOrgAdmin
+			}
+		}
+		// novo token_version: os tokens com o papel antigo deixam de valer na hora (como no logout)
+		return tx.Model(&user).Updates(map[string]interface{}{"role": body.Role, "token_version": gorm.Expr("token_version + 1")}).Error
+	})
+	if errors.Is(err, errLastOrgAdmin) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	user.Role = body.Role
+	h.hub.DisconnectUser(user.ID, "role changed")
+	return c.JSON(user)
+}
+
+// ListAll lista todas as organizações (super-admin)
+func (h *OrgHandler) ListAll(c *fiber.Ctx) error {
+	orgs := []models.Organization{}
+	if err := h.db.Order("id").Find(&orgs).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.JSON(orgs)
+}
+
+// Create cria uma organização com o seu primeiro org_admin (super-admin)
+func (h *OrgHandler) Create(c *fiber.Ctx) error {
+	var body struct {
+		Name string `json:"name"`
+	}
+	if err := c.BodyParser(&body); err != nil || !validProjectName(body.Name) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "name must have 1 to 100 characters"})
+	}
+	org := models.Organization{Name: strings.TrimSpace(body.Name)}
+	if err := h.db.Create(&org).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.Status(fiber.StatusCreated).JSON(org)
+}
+
+// AddUserTo cria uma conta em qualquer organização (super-admin)
+func (h *OrgHandler) AddUserTo(c *fiber.Ctx) error {
+	var org models.Organization
+	if err := h.db.First(&org, "id = ?", c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "organization not found"})
+	}
+	user, status, msg := createOrgUser(c, h.db, org.ID)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"error": msg})
+	}
+	return c.Status(fiber.StatusCreated).JSON(user)
+}
+
+// createOrgUser lê {name, email, password, role} e cria o usuário na organização
+func createOrgUser(c *fiber.Ctx, db *gorm.DB, orgID uint) (models.User, int, string) {
+	var body struct {
+		Name     string `json:"name"`
+		Email    string `json:"email"`
+		Password string `json:"password"`
+		Role     string `json:"role"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return models.User{}, fiber.StatusBadRequest, "invalid JSON"
+	}
+	body.Email = strings.ToLower(strings.TrimSpace(body.Email))
+	if body.Role == "" {
+		body.Role = models.RoleUser
+	}
+	if body.Name == "" || body.Email == "" || len(body.Password) < 6 || !contains(models.OrgRoles, body.Role) {
+		return models.User{}, fiber.StatusBadRequest, "invalid data"
+	}
+	var count int64
+	db.Model(&models.User{}).Where("email = ?", body.Email).Count(&count)
+	if count > 0 {
+		return models.User{}, fiber.StatusConflict, "email already registered"
+	}
+	hash, _ := bcrypt.GenerateFromPassword([]byte(body.Password), bcrypt.DefaultCost)
+	user := models.User{Name: body.Name, Email: body.Email, PasswordHash: string(hash), Role: body.Role, OrgID: orgID}
+	if err := db.Create(&user).Error; err != nil {
+		return models.User{}, fiber.StatusInternalServerError, "internal error"
+	}
+	return user, 0, ""
+}
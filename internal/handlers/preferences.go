@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"goTasks/internal/models"
+	"goTasks/internal/notify"
+)
+
+type PreferencesHandler struct {
+	db *gorm.DB
+}
+
+func NewPreferencesHandler(db *gorm.DB) *PreferencesHandler {
+	return &PreferencesHandler{db: db}
+}
+
+// Get devolve as preferências de notificação do usuário autenticado
+func (h *PreferencesHandler) Get(c *fiber.Ctx) error {
+	uid, ok := c.Locals("userID").(uint)
+	if !ok || uid == 0 {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	p, err := notify.LoadPreferences(h.db, uid)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.JSON(p)
+}
+
+// Update altera parcialmente as preferências (campos ausentes ficam como estão)
+func (h *PreferencesHandler) Update(c *fiber.Ctx) error {
+	uid, ok := c.Locals("userID").(uint)
+	if !ok || uid == 0 {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	p, err := notify.LoadPreferences(h.db, uid)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+
+	var body struct {
+		Channels           map[string][]string `json:"channels"`
+		DueSoonLeadMinutes *int                `json:"dueSoonLeadMinutes"`
+		QuietHoursStart    *string             `json:"quietHoursStart"`
+		QuietHoursEnd      *string             `json:"quietHoursEnd"`
+		Timezone           *string             `json:"timezone"`
+		DigestEnabled      *bool               `json:"digestEnabled"`
+		DigestHour         *int                `json:"digestHour"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid data"})
+	}
+
+	if body.Channels != nil {
+		if msg := validateChannels(body.Channels); msg != "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+		}
+		p.Channels = body.Channels
+	}
+	if body.DueSoonLeadMinutes != nil {
+		lead := *body.DueSoonLeadMinutes
+		if lead < 1 || lead > 30*24*60 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "dueSoonLeadMinutes must be between 1 and 43200"})
+		}
+		p.DueSoonLeadMinutes = lead
+	}
+	if body.QuietHoursStart != nil {
+		p.QuietHoursStart = *body.QuietHoursStart
+	}
+	if body.QuietHoursEnd != nil {
+		p.QuietHoursEnd = *body.QuietHoursEnd
+	}
+	if (p.QuietHoursStart == "") != (p.QuietHoursEnd == "") ||
+		(p.QuietHoursStart != "" && (!models.ValidClock(p.QuietHoursStart) || !models.ValidClock(p.QuietHoursEnd))) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "quiet hours must be HH:MM pairs"})
+	}
+	if body.Timezone != nil {
+		if _, err := time.LoadLocation(*body.Timezone); err != nil || *body.Timezone == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid timezone"})
+		}
+		p.Timezone = *body.Timezone
+	}
+	if body.DigestEnabled != nil {
+		p.DigestEnabled = *body.DigestEnabled
+	}
+	if body.DigestHour != nil {
+		if *body.DigestHour < 0 || *body.DigestHour > 23 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "digestHour must be between 0 and 23"})
+		}
+		p.DigestHour = *body.DigestHour
+	}
+
+	p.UserID = uid
+	if err := h.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&p).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.JSON(p)
+}
+
+func validateChannels(channels map[string][]string) string {
+	for typ, chans := range channels {
+		if typ != "*" && !contains(models.NotificationTypes, typ) {
+			return "unknown notification type: " + typ
+		}
+		for _, ch := range chans {
+			if !contains(models.NotificationChannels, ch) {
+				return "unknown channel: " + ch
+			}
+		}
+	}
+	return ""
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
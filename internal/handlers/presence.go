@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"goTasks/internal/models"
+	"goTasks/internal/policy"
+	"goTasks/internal/ws"
+)
+
+// TaskAudience devolve quem pode acompanhar uma tarefa em tempo real (ver policy.Audience).
+func TaskAudience(db *gorm.DB) ws.AudienceFunc {
+	return func(taskID uint) ([]uint, error) {
+		var task models.Task
+		if err := db.Select("id", "owner_id", "org_id", "project_id").First(&task, "id = ?", taskID).Error; err != nil {
+			return nil, err
+		}
+		return policy.Audience(db, task), nil
+	}
+}
+
+type PresenceHandler struct {
+	db       *gorm.DB
+	presence *ws.Presence
+}
+
+func NewPresenceHandler(db *gorm.DB, hub *ws.Hub) *PresenceHandler {
+	return &PresenceHandler{db: db, presence: hub.Presence()}
+}
+
+// Online lista os usuários da organização conectados ao /ws
+func (h *PresenceHandler) Online(c *fiber.Ctx) error {
+	ids := h.presence.Online()
+	users := []models.User{}
+	if len(ids) > 0 {
+		qry := h.db.Select("id", "name").Where("id IN ?", ids)
+		if a := actor(c); !a.Admin {
+			qry = qry.Where("org_id = ?", a.OrgID)
+		}
+		if err := qry.Order("id").Find(&users).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+		}
+	}
+	out := make([]fiber.Map, 0, len(users))
+	for _, u := range users {
+		out = append(out, fiber.Map{"id": u.ID, "name": u.Name})
+	}
+	return c.JSON(out)
+}
+
+// TaskViewers lista quem está vendo/editando a tarefa agora
+func (h *PresenceHandler) TaskViewers(c *fiber.Ctx) error {
+	var task models.Task
+	if err := h.db.First(&task, "id = ?", c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
+	}
+	uid, ok := c.Locals("userID").(uint)
+	if !ok || uid == 0 {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	if role, err := policy.OnTask(h.db, actor(c), task); err != nil || role < policy.Viewer {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
+	}
+	return c.JSON(h.presence.Viewers(task.ID))
+}
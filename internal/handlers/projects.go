@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"goTasks/internal/models"
+	"goTasks/internal/policy"
+	"goTasks/internal/trash"
+	"goTasks/internal/ws"
+)
+
+var errLastProjectOwner = errors.New("a project needs at least one owner")
+
+type ProjectHandler struct {
+	db  *gorm.DB
+	hub *ws.Hub
+}
+
+func NewProjectHandler(db *gorm.DB, hub *ws.Hub) *ProjectHandler {
+	return &ProjectHandler{db: db, hub: hub}
+}
+
+// project carrega o projeto se o usuário tiver pelo menos o papel need nele
+func (h *ProjectHandler) project(c *fiber.Ctx, need policy.Role) (models.Project, bool, error) {
+	var p models.Project
+	if err := h.db.First(&p, "id = ?", c.Params("id")).Error; err != nil {
+		return p, false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "project not found"})
+	}
+	role, err := policy.OnProject(h.db, actor(c), p.ID)
+	if err != nil {
+		return p, false, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	if role == policy.None {
+		return p, false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "project not found"})
+	}
+	if role < need {
+		return p, false, c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "not allowed"})
+	}
+	return p, true, nil
+}
+
+func (h *ProjectHandler) members(projectID uint) []uint {
+	var ids []uint
+	h.db.Model(&models.ProjectMember{}).Where("project_id = ?", projectID).Pluck("user_id", &ids)
+	return ids
+}
+
+// List devolve os projetos de que o usuário é membro (org_admin vê os da organização; admin, todos)
+func (h *ProjectHandler) List(c *fiber.Ctx) error {
+	a := actor(c)
+	projects := []models.Project{}
+	qry := h.db.Order("name, id")
+	switch {
+	case a.Admin:
+	case a.OrgAdmin:
+		qry = qry.Where("org_id = ?", a.OrgID)
+	default:
+		qry = qry.Where("org_id = ? AND id IN (?)", a.OrgID, h.db.Model(&models.ProjectMember{}).Select("project_id").Where("user_id = ?", a.ID))
+	}
+	if err := qry.Find(&projects).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.JSON(projects)
+}
+
+// Create cria o projeto com quem o criou como dono
+func (h *ProjectHandler) Create(c *fiber.Ctx) error {
+	uid, ok := c.Locals("userID").(uint)
+	if !ok || uid == 0 {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	var body struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	if err := c.BodyParser(&body); err != nil || !validProjectName(body.Name) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "name must have 1 to 100 characters"})
+	}
+	p := models.Project{
+		Name:        strings.TrimSpace(body.Name),
+		Description: body.Description,
+		OrgID:       actor(c).OrgID,
+		Members:     []models.ProjectMember{{UserID: uid, Role: models.ProjectOwner}},
+	}
+	if err := h.db.Omit("Members.User").Create(&p).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.Status(fiber.StatusCreated).JSON(p)
+}
+
+// Get devolve o projeto com os membros
+func (h *ProjectHandler) Get(c *fiber.Ctx) error {
+	p, ok, err := h.project(c, policy.Viewer)
+	if !ok {
+		return err
+	}
+	if err := h.db.Preload("Members", func(db *gorm.DB) *gorm.DB { return db.Order("created_at, user_id") }).
+		Preload("Members.User").First(&p, p.ID).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.JSON(p)
+}
+
+func (h *ProjectHandler) Update(c *fiber.Ctx) error {
+	p, ok, err := h.project(c, policy.Owner)
+	if !ok {
+		return err
+	}
+	var body struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid data"})
+	}
+	updates := map[string]interface{}{}
+	if body.Name != nil {
+		if !validProjectName(*body.Name) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "name must have 1 to 100 characters"})
+		}
+		p.Name = strings.TrimSpace(*body.Name)
+		updates["name"] = p.Name
+	}
+	if body.Description != nil {
+		p.Description = *body.Description
+		updates["description"] = p.Description
+	}
+	if len(updates) > 0 {
+		if err := h.db.Model(&p).Updates(updates).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+		}
+		h.hub.Broadcast(ws.Event{Type: "project.updated", Payload: p, To: h.members(p.ID), Org: p.OrgID})
+	}
+	return c.JSON(p)
+}
+
+// Delete apaga um projeto vazio; as tarefas precisam ser movidas ou apagadas antes
+// (as que estão na lixeira são apagadas de vez junto com o projeto)
+func (h *ProjectHandler) Delete(c *fiber.Ctx) error {
+	p, ok, err := h.project(c, policy.Owner)
+	if !ok {
+		return err
+	}
+	var tasks int64
+	if err := h.db.Model(&models.Task{}).Where("project_id = ?", p.ID).Count(&tasks).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	if tasks > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "project still has tasks"})
+	}
+	audience := h.members(p.ID)
+	var trashed []uint
+	if err := h.db.Unscoped().Model(&models.Task{}).Where("project_id = ?", p.ID).Pluck("id", &trashed).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := trash.PurgeTasks(tx, trashed); err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM task_labels WHERE label_id IN (?)", tx.Model(&models.Label{}).Select("id").Where("project_id = ?", p.ID)).Error; err != nil {
+			return err
+		}
+		for _, m := range []interface{}{&models.ProjectMember{}, &models.ChatChannel{}, &models.Workflow{}, &models.Label{}} {
+			if err := tx.Where("project_id = ?", p.ID).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&p).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	h.hub.Broadcast(ws.Event{Type: "project.deleted", Payload: fiber.Map{"id": p.ID}, To: audience, Org: p.OrgID})
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// AddMember inclui um usuário com papel owner, editor ou viewer
+func (h *ProjectHandler) AddMember(c *fiber.Ctx) error {
+	p, ok, err := h.project(c, policy.Owner)
+	if !ok {
+		return err
+	}
+	var body struct {
+		UserID uint   `json:"userId"`
+		Role   string `json:"role"`
+	}
+	if err := c.BodyParser(&body); err != nil || !contains(models.ProjectRoles, body.Role) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "role must be owner, editor or viewer"})
+	}
+	if _, ok := existingUsers(h.db, p.OrgID, []uint{body.UserID}); !ok || body.UserID == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user"})
+	}
+	var count int64
+	h.db.Model(&models.ProjectMember{}).Where("project_id = ? AND user_id = ?", p.ID, body.UserID).Count(&count)
+	if count > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "user is already a member"})
+	}
+	m := models.ProjectMember{ProjectID: p.ID, UserID: body.UserID, Role: body.Role}
+	if err := h.db.Omit("User").Create(&m).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	h.hub.Broadcast(ws.Event{Type: "project.member.added", Payload: m, To: h.members(p.ID), Org: p.OrgID})
+	return c.Status(fiber.StatusCreated).JSON(m)
+}
+
+// UpdateMember troca o papel de um membro
+func (h *ProjectHandler) UpdateMember(c *fiber.Ctx) error {
+	p, ok, err := h.project(c, policy.Owner)
+	if !ok {
+		return err
+	}
+	var body struct {
+		Role string `json:"role"`
+	}
+	if err := c.BodyParser(&body); err != nil || !contains(models.ProjectRoles, body.Role) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "role must be owner, editor or viewer"})
+	}
+	m, ok := h.member(p.ID, c.Params("userId"))
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "member not found"})
+	}
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := guardOwners(tx, p.ID, m.UserID, body.Role == models.ProjectOwner); err != nil {
+			return err
+		}
+		return tx.Model(&models.ProjectMember{}).Where("project_id = ? AND user_id = ?", p.ID, m.UserID).
+			Update("role", body.Role).Error
+	})
+	if errors.Is(err, errLastProjectOwner) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "member not found"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	m.Role = body.Role
+	h.hub.Broadcast(ws.Event{Type: "project.member.updated", Payload: m, To: h.members(p.ID), Org: p.OrgID})
+	return c.JSON(m)
+}
+
+// RemoveMember tira um membro; qualquer membro pode sair do projeto
+func (h *ProjectHandler) RemoveMember(c *fiber.Ctx) error {
+	a := actor(c)
+	need := policy.Owner
+	if uid, err := c.ParamsInt("userId"); err == nil && uint(uid) == a.ID {
+		need = policy.Viewer
+	}
+	p, ok, err := h.project(c, need)
+	if !ok {
+		return err
+	}
+	m, ok := h.member(p.ID, c.Params("userId"))
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "member not found"})
+	}
+	audience := h.members(p.ID)
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := guardOwners(tx, p.ID, m.UserID, false); err != nil {
+			return err
+		}
+		return tx.Where("project_id = ? AND user_id = ?", p.ID, m.UserID).Delete(&models.ProjectMember{}).Error
+	})
+	if errors.Is(err, errLastProjectOwner) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "member not found"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	h.hub.Broadcast(ws.Event{Type: "project.member.removed", Payload: fiber.Map{"projectId": p.ID, "userId": m.UserID}, To: audience, Org: p.OrgID})
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *ProjectHandler) member(projectID uint, userID string) (models.ProjectMember, bool) {
+	var m models.ProjectMember
+	err := h.db.First(&m, "project_id = ? AND user_id = ?", projectID, userID).Error
+	return m, err == nil
+}
+
+// guardOwners trava o projeto e recusa a mudança que tiraria o último dono (keepsOwner = o membro
+// continua dono). Com a trava, duas trocas simultâneas não deixam o projeto sem dono.
+func guardOwners(tx *gorm.DB, projectID, userID uint, keepsOwner bool) error {
+	var p models.Project
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&p, "id = ?", projectID).Error; err != nil {
+		return err
+	}
+	var m models.ProjectMember
+	if err := tx.First(&m, "project_id = ? AND user_id = ?", projectID, userID).Error; err != nil {
+		return err
+	}
+	if m.Role != models.ProjectOwner || keepsOwner {
+		return nil
+	}
+	var owners int64
+	if err := tx.Model(&models.ProjectMember{}).Where("project_id = ? AND role = ?", projectID, models.ProjectOwner).Count(&owners).Error; err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return errLastProjectOwner
+	}
+	return nil
+}
+
+// projectInOrg confere que o projeto é da organização (o super-admin passa pelas checagens de papel).
+func projectInOrg(db *gorm.DB, projectID, orgID uint) bool {
+	var count int64
+	db.Model(&models.Project{}).Where("id = ? AND org_id = ?", projectID, orgID).Count(&count)
+	return count > 0
+}
+
+func validProjectName(name string) bool {
+	name = strings.TrimSpace(name)
+	return name != "" && len(name) <= 100
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"goTasks/internal/history"
+	"goTasks/internal/models"
+	"goTasks/internal/notify"
+	"goTasks/internal/recurrence"
+)
+
+// Escopo de uma edição em tarefa recorrente
+const (
+	ScopeThis   = "this"
+	ScopeFuture = "future"
+)
+
+var errRecurrenceNeedsDue = errors.New("recurrence requires dueDate")
+
+// startSeries transforma a tarefa na primeira ocorrência (ou na âncora) de uma nova série.
+func startSeries(tx *gorm.DB, task *models.Task, rule recurrence.Rule) error {
+	if task.DueDate == nil {
+		return errRecurrenceNeedsDue
+	}
+	p, err := notify.LoadPreferences(tx, task.OwnerID)
+	if err != nil {
+		return err
+	}
+	occurrence := task.Occurrence
+	if occurrence == 0 {
+		occurrence = 1
+	}
+	series := models.TaskSeries{
+		OwnerID:         task.OwnerID,
+		RRule:           rule.String(),
+		Timezone:        p.Location().String(),
+		Start:           *task.DueDate,
+		StartOccurrence: occurrence,
+		Occurrences:     occurrence,
+		Title:           task.Title,
+		Description:     task.Description,
+	}
+	if err := tx.Create(&series).Error; err != nil {
+		return err
+	}
+	task.SeriesID, task.Occurrence = &series.ID, occurrence
+	return nil
+}
+
+// updateSeries aplica uma edição "esta e as futuras": atualiza o modelo da série,
+// as ocorrências em aberto seguintes e, se a regra ou o vencimento mudou, reancora a série nesta tarefa.
+func updateSeries(tx *gorm.DB, task models.Task, changes map[string]interface{}, rrule *string, dueChanged bool, meta history.Meta) error {
+	var series models.TaskSeries
+	if err := tx.First(&series, "id = ?", *task.SeriesID).Error; err != nil {
+		return err
+	}
+	updates := map[string]interface{}{}
+	for _, f := range []string{"title", "description"} {
+		if v, ok := changes[f]; ok {
+			updates[f] = v
+		}
+	}
+	if len(updates) > 0 {
+		var ids []uint
+		if err := tx.Model(&models.Task{}).
+			Where("series_id = ? AND occurrence > ? AND status_category <> ?", series.ID, task.Occurrence, models.CategoryDone).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		// cada ocorrência alterada ganha sua própria revisão e uma nova versão
+		taskUpdates := map[string]interface{}{"version": gorm.Expr("version + 1")}
+		for k, v := range updates {
+			taskUpdates[k] = v
+		}
+		for _, id := range ids {
+			before, _, err := history.Take(tx, id)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Task{}).Where("id = ?", id).Updates(taskUpdates).Error; err != nil {
+				return err
+			}
+			if err := history.Record(tx, id, &before, meta); err != nil {
+				return err
+			}
+		}
+	}
+	if rrule != nil && *rrule == "" {
+		now := time.Now()
+		updates["ended_at"] = &now
+	} else if rrule != nil || dueChanged {
+		if task.DueDate == nil {
+			return errRecurrenceNeedsDue
+		}
+		if rrule != nil {
+			updates["rrule"] = *rrule
+			updates["ended_at"] = nil
+		}
+		updates["start"] = *task.DueDate
+		updates["start_occurrence"] = task.Occurrence
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return tx.Model(&series).Updates(updates).Error
+}
+
+// spawnNext gera a próxima ocorrência quando a última da série é concluída; roda na mesma
+// transação que conclui a tarefa, para as duas coisas acontecerem juntas ou nenhuma.
+// Devolve nil se a série acabou (COUNT/UNTIL/encerrada) ou se a próxima já existe.
+func spawnNext(tx *gorm.DB, task models.Task) (*models.Task, error) {
+	if task.SeriesID == nil || task.DueDate == nil {
+		return nil, nil
+	}
+	var series models.TaskSeries
+	if err := tx.First(&series, "id = ?", *task.SeriesID).Error; err != nil {
+		return nil, err
+	}
+	if series.EndedAt != nil || task.Occurrence < series.Occurrences {
+		return nil, nil
+	}
+	rule, err := recurrence.Parse(series.RRule)
+	if err != nil {
+		return nil, err
+	}
+	if rule.Count > 0 && task.Occurrence+1-series.StartOccurrence >= rule.Count {
+		return nil, nil
+	}
+	loc, err := time.LoadLocation(series.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	due, ok := rule.Next(series.Start.In(loc), task.DueDate.In(loc))
+	if !ok {
+		return nil, nil
+	}
+	due = due.UTC()
+	wf, err := workflowFor(tx, task.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	position, err := endOfColumn(tx, boardColumn{ProjectID: task.ProjectID, OwnerID: task.OwnerID, Status: wf.Initial()}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	next := models.Task{
+		Title:          series.Title,
+		Description:    series.Description,
+		Status:         wf.Initial(),
+		StatusCategory: wf.Category(wf.Initial()),
+		Priority:       task.Priority,
+		OrgID:          task.OrgID,
+		ProjectID:      task.ProjectID,
+		DueDate:        &due,
+		OwnerID:        task.OwnerID,
+		SeriesID:       task.SeriesID,
+		Occurrence:     task.Occurrence + 1,
+		Version:        1,
+		Position:       position,
+	}
+	// (series_id, occurrence) é único: concluir duas vezes em paralelo gera uma só
+	res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&next)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return nil, res.Error
+	}
+	if err := tx.Model(&series).Update("occurrences", next.Occurrence).Error; err != nil {
+		return nil, err
+	}
+	// etiquetas, responsáveis e observadores seguem para a próxima ocorrência
+	for table, col := range map[string]string{"task_labels": "label_id", models.TaskAssigneesTable: "user_id", models.TaskWatchersTable: "user_id"} {
+		if err := tx.Exec("INSERT INTO "+table+" (task_id, "+col+") SELECT ?, "+col+" FROM "+table+" WHERE task_id = ?", next.ID, task.ID).Error; err != nil {
+			return nil, err
+		}
+	}
+	// lembretes relativos ao vencimento seguem para a próxima ocorrência
+	var reminders []models.TaskReminder
+	if err := tx.Where("task_id = ? AND offset_minutes IS NOT NULL", task.ID).Find(&reminders).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range reminders {
+		rem := models.TaskReminder{TaskID: next.ID, UserID: r.UserID, OffsetMinutes: r.OffsetMinutes}
+		rem.Schedule(next.DueDate)
+		if err := tx.Create(&rem).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := history.Record(tx, next.ID, nil, history.Meta{Source: models.SourceScheduler}); err != nil {
+		return nil, err
+	}
+	return &next, nil
+}
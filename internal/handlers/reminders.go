@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"goTasks/internal/models"
+	"goTasks/internal/policy"
+)
+
+// maxReminderOffset: até um ano antes do vencimento
+const maxReminderOffset = 365 * 24 * 60
+
+type ReminderHandler struct {
+	db *gorm.DB
+}
+
+func NewReminderHandler(db *gorm.DB) *ReminderHandler {
+	return &ReminderHandler{db: db}
+}
+
+// task carrega a tarefa se o usuário puder vê-la
+func (h *ReminderHandler) task(c *fiber.Ctx) (models.Task, uint, error) {
+	var task models.Task
+	uid, ok := c.Locals("userID").(uint)
+	if !ok || uid == 0 {
+		return task, 0, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	if err := h.db.First(&task, "id = ?", c.Params("id")).Error; err != nil {
+		return task, 0, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
+	}
+	if role, err := policy.OnTask(h.db, actor(c), task); err != nil || role < policy.Viewer {
+		return task, 0, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
+	}
+	return task, uid, nil
+}
+
+// List devolve os lembretes do usuário na tarefa
+func (h *ReminderHandler) List(c *fiber.Ctx) error {
+	task, uid, err := h.task(c)
+	if uid == 0 {
+		return err
+	}
+	reminders := []models.TaskReminder{}
+	if err := h.db.Where("task_id = ? AND user_id = ?", task.ID, uid).Order("fire_at ASC, id ASC").Find(&reminders).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.JSON(reminders)
+}
+
+// Create adiciona um lembrete: {"at": "..."} ou {"offsetMinutes": 60} (antes do vencimento)
+func (h *ReminderHandler) Create(c *fiber.Ctx) error {
+	task, uid, err := h.task(c)
+	if uid == 0 {
+		return err
+	}
+	var body struct {
+		At            *time.Time `json:"at"`
+		OffsetMinutes *int       `json:"offsetMinutes"`
+	}
+	if err := c.BodyParser(&body); err != nil || (body.At == nil) == (body.OffsetMinutes == nil) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "provide either at or offsetMinutes"})
+	}
+	if body.At != nil && !body.At.After(time.Now()) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "at must be in the future"})
+	}
+	if body.OffsetMinutes != nil && (*body.OffsetMinutes < 0 || *body.OffsetMinutes > maxReminderOffset) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid offsetMinutes"})
+	}
+
+	var count int64
+	if err := h.db.Model(&models.TaskReminder{}).Where("task_id = ? AND user_id = ?", task.ID, uid).Count(&count).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	if count >= models.MaxRemindersPerTask {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": "too many reminders"})
+	}
+
+	r := models.TaskReminder{TaskID: task.ID, UserID: uid, At: body.At, OffsetMinutes: body.OffsetMinutes}
+	r.Schedule(task.DueDate)
+	if err := h.db.Create(&r).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.Status(fiber.StatusCreated).JSON(r)
+}
+
+// Delete remove um lembrete do usuário
+func (h *ReminderHandler) Delete(c *fiber.Ctx) error {
+	task, uid, err := h.task(c)
+	if uid == 0 {
+		return err
+	}
+	res := h.db.Where("id = ? AND task_id = ? AND user_id = ?", c.Params("reminderId"), task.ID, uid).Delete(&models.TaskReminder{})
+	if res.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	if res.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "reminder not found"})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
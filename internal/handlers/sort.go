@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// taskSortFields: chaves aceitas em ?sort= -> coluna
+var taskSortFields = map[string]string{
+	"priority":  "priority",
+	"dueDate":   "due_date",
+	"createdAt": "created_at",
+	"updatedAt": "updated_at",
+	"title":     "title",
+	"status":    "status",
+}
+
+// nullableSortFields vão para o fim da lista quando nulos, em qualquer direção
+var nullableSortFields = map[string]bool{"due_date": true}
+
+const defaultTaskSort = "-createdAt"
+
+// parseSort converte "-priority,dueDate" em cláusulas ORDER BY seguras (só campos da whitelist),
+// terminando em id para a paginação ser estável.
+func parseSort(raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		raw = defaultTaskSort
+	}
+	var out []string
+	seen := map[string]bool{}
+	lastDir := "DESC"
+	for _, key := range strings.Split(raw, ",") {
+		key = strings.TrimSpace(key)
+		dir := "ASC"
+		if strings.HasPrefix(key, "-") {
+			dir, key = "DESC", key[1:]
+		} else {
+			key = strings.TrimPrefix(key, "+")
+		}
+		col, ok := taskSortFields[key]
+		if !ok {
+			return nil, fmt.Errorf("invalid sort field %q", key)
+		}
+		if seen[col] {
+			continue
+		}
+		seen[col] = true
+		if nullableSortFields[col] {
+			// "IS NULL" ordena false antes de true tanto no Postgres quanto no SQLite
+			out = append(out, col+" IS NULL")
+		}
+		out = append(out, col+" "+dir)
+		lastDir = dir
+	}
+	return append(out, "id "+lastDir), nil
+}
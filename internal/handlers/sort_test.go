@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSort(t *testing.T) {
+	got, err := parseSort("-priority, dueDate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"priority DESC", "due_date IS NULL", "due_date ASC", "id ASC"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if got, _ := parseSort(""); !reflect.DeepEqual(got, []string{"created_at DESC", "id DESC"}) {
+		t.Fatalf("unexpected default sort %v", got)
+	}
+	for _, bad := range []string{"owner_id", "priority;DROP TABLE tasks", "-"} {
+		if _, err := parseSort(bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"goTasks/internal/models"
+	"goTasks/internal/policy"
+	"goTasks/internal/ws"
+)
+
+var (
+	errSubtaskDepth = fmt.Errorf("subtasks can be nested at most %d levels deep", models.MaxSubtaskDepth)
+	errSubtaskCycle = errors.New("a task cannot be moved under itself or its subtasks")
+)
+
+// loadProgress preenche o progresso (subtarefas diretas e checklist) de cada tarefa, com duas consultas agrupadas.
+func loadProgress(db *gorm.DB, tasks []models.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(tasks))
+	for i := range tasks {
+		ids[i] = tasks[i].ID
+		tasks[i].Progress = &models.TaskProgress{}
+	}
+	type row struct {
+		ID    uint
+		Done  int
+		Total int
+	}
+	var subtasks, items []row
+	if err := db.Model(&models.Task{}).
+		Select("parent_id AS id, SUM(CASE WHEN status_category = ? THEN 1 ELSE 0 END) AS done, COUNT(*) AS total", models.CategoryDone).
+		Where("parent_id IN ?", ids).Group("parent_id").Scan(&subtasks).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&models.ChecklistItem{}).
+		Select("task_id AS id, SUM(CASE WHEN done THEN 1 ELSE 0 END) AS done, COUNT(*) AS total").
+		Where("task_id IN ?", ids).Group("task_id").Scan(&items).Error; err != nil {
+		return err
+	}
+	byID := make(map[uint]*models.TaskProgress, len(tasks))
+	for i := range tasks {
+		byID[tasks[i].ID] = tasks[i].Progress
+	}
+	for _, r := range subtasks {
+		byID[r.ID].Subtasks = models.Progress{Done: r.Done, Total: r.Total}
+	}
+	for _, r := range items {
+		byID[r.ID].Checklist = models.Progress{Done: r.Done, Total: r.Total}
+	}
+	return nil
+}
+
+// taskDepth devolve quantos ancestrais a tarefa tem (0 = raiz).
+func taskDepth(db *gorm.DB, id uint) (int, error) {
+	depth := 0
+	for {
+		var t models.Task
+		if err := db.Select("id", "parent_id").First(&t, "id = ?", id).Error; err != nil {
+			return 0, err
+		}
+		if t.ParentID == nil {
+			return depth, nil
+		}
+		depth++
+		if depth > models.MaxSubtaskDepth {
+			return depth, nil
+		}
+		id = *t.ParentID
+	}
+}
+
+// descendants lista, nível a nível, as subtarefas abaixo de id; height é o número de níveis.
+func descendants(db *gorm.DB, id uint) (ids []uint, height int, err error) {
+	level := []uint{id}
+	for len(level) > 0 {
+		var next []uint
+		if err := db.Model(&models.Task{}).Where("parent_id IN ?", level).Pluck("id", &next).Error; err != nil {
+			return nil, 0, err
+		}
+		if len(next) > 0 {
+			height++
+		}
+		ids = append(ids, next...)
+		level = next
+	}
+	return ids, height, nil
+}
+
+// checkParent valida mover (ou criar) a tarefa taskID, com subárvore de altura height, para baixo de parentID.
+func checkParent(db *gorm.DB, taskID, parentID uint, height int) error {
+	depth, err := taskDepth(db, parentID)
+	if err != nil {
+		return err
+	}
+	if depth+1+height > models.MaxSubtaskDepth {
+		return errSubtaskDepth
+	}
+	if taskID == 0 {
+		return nil
+	}
+	// o novo pai não pode estar na própria subárvore
+	for id, i := parentID, 0; i <= models.MaxSubtaskDepth; i++ {
+		if id == taskID {
+			return errSubtaskCycle
+		}
+		var t models.Task
+		if err := db.Select("id", "parent_id").First(&t, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if t.ParentID == nil {
+			break
+		}
+		id = *t.ParentID
+	}
+	return nil
+}
+
+// trashTaskTree manda a tarefa, as subtarefas e os comentários delas para a lixeira,
+// todos com o mesmo instante: é por ele que a restauração sabe o que voltar junto.
+func trashTaskTree(tx *gorm.DB, id uint, at time.Time) error {
+	ids, _, err := descendants(tx, id)
+	if err != nil {
+		return err
+	}
+	ids = append(ids, id)
+	if err := tx.Model(&models.Comment{}).Where("task_id IN ?", ids).UpdateColumn("deleted_at", at).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Task{}).Where("id IN ?", ids).UpdateColumn("deleted_at", at).Error
+}
+
+// trashedTree lista a tarefa e as subtarefas excluídas junto com ela.
+func trashedTree(db *gorm.DB, id uint, at time.Time) ([]uint, error) {
+	ids := []uint{id}
+	for level := ids; len(level) > 0; {
+		var next []uint
+		if err := db.Unscoped().Model(&models.Task{}).Where("parent_id IN ? AND deleted_at = ?", level, at).Pluck("id", &next).Error; err != nil {
+			return nil, err
+		}
+		ids, level = append(ids, next...), next
+	}
+	return ids, nil
+}
+
+// broadcastProgress avisa que o progresso da tarefa mudou (subtarefa ou item de checklist).
+func broadcastProgress(db *gorm.DB, hub *ws.Hub, taskID uint) {
+	var task models.Task
+	if err := db.Select("id", "owner_id", "org_id", "project_id").First(&task, "id = ?", taskID).Error; err != nil {
+		return
+	}
+	tasks := []models.Task{task}
+	if err := loadProgress(db, tasks); err != nil {
+		return
+	}
+	hub.Broadcast(ws.Event{Type: "task.progress", Payload: fiber.Map{"taskId": task.ID, "progress": tasks[0].Progress}, To: policy.Audience(db, task), Org: task.OrgID})
+}
+
+// sameID compara ids opcionais (mãe, projeto).
+func sameID(a, b *uint) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
@@ -38,21 +38,129 @@ const swaggerSpec = `{
   "openapi": "3.0.0",
   "info": { "title": "goTasks API", "version": "1.0.0" },
   "paths": {
-    "/api/auth/register": { "post": { "summary": "Register", "responses": { "200": { "description": "OK" } } } },
+    "/api/auth/register": { "post": { "summary": "Register a new organization (optional organization name); the user becomes its org_admin", "responses": { "200": { "description": "OK" } } } },
     "/api/auth/login": { "post": { "summary": "Login", "responses": { "200": { "description": "OK" } } } },
+    "/api/auth/logout": { "post": { "summary": "Revoke all sessions of the current user", "responses": { "204": { "description": "No Content" } } } },
+    "/api/ws/ticket": { "post": { "summary": "Single-use ticket for /ws?ticket=", "responses": { "201": { "description": "Created" } } } },
     "/api/tasks": {
-      "get": { "summary": "List tasks", "responses": { "200": { "description": "OK" } } },
-      "post": { "summary": "Create task", "responses": { "201": { "description": "Created" } } }
+      "get": { "summary": "List tasks of my organization that I own, am assigned to or watch, or that belong to my projects (org_admin sees all of the organization; admin may filter by orgId), with progress rollup (status, category=not_started|in_progress|done, priority, q, parentId, projectId, assignee=me|id, watching=true, labels=a,b with all, anyLabel=a,b with any, among my personal labels and my projects' labels; sort=-priority,dueDate over priority|dueDate|createdAt|updatedAt|title|status, default -createdAt)", "responses": { "200": { "description": "OK" } } },
+      "post": { "summary": "Create task (status must exist in the project's workflow, default is its first status, 422 lists the valid ones; projectId needs editor role, subtasks inherit the parent's project; priority none|low|medium|high|urgent; labelIds (owner's personal labels or the project's labels); assigneeIds; watcherIds; parentId makes it a subtask, max 3 levels; optional recurrence RRULE: FREQ=DAILY|WEEKLY|MONTHLY, INTERVAL, BYDAY, BYMONTHDAY, COUNT, UNTIL)", "responses": { "201": { "description": "Created" } } }
     },
     "/api/tasks/{id}": {
-      "get": { "summary": "Get task", "responses": { "200": { "description": "OK" } } },
-      "patch": { "summary": "Update task", "responses": { "200": { "description": "OK" } } },
-      "delete": { "summary": "Delete task", "responses": { "204": { "description": "No Content" } } }
+      "get": { "summary": "Get task with subtask and checklist progress; the ETag header is the task version", "responses": { "200": { "description": "OK" } } },
+      "patch": { "summary": "Update task as owner, assignee or project editor; only the owner changes ownerId, assigneeIds or projectId (0 removes it from the project; status changes follow the project's workflow transitions, 422 lists the valid next statuses; 409 with open blockers when moving into a done-category status, unless an admin passes force=true; labelIds, watcherIds and assigneeIds replace the lists; parentId moves it, 0 detaches; scope=this|future for recurring tasks; every change is recorded in the history); If-Match with the ETag from GET makes the update conditional, and each update bumps version", "responses": { "200": { "description": "OK" }, "412": { "description": "Changed by someone else; body is the current task" } } },
+      "delete": { "summary": "Move task, its subtasks and their comments to the trash (owner)", "responses": { "204": { "description": "No Content" } } }
+    },
+    "/api/tasks/{id}/move": { "post": { "summary": "Move task on the board: optional target status (follows workflow transitions) and neighbors afterId/beforeId in the target column, none = end of the column; only the moved task is rewritten; honors If-Match; broadcasts task.moved and task.updated with the full task", "responses": { "200": { "description": "OK" }, "412": { "description": "Changed by someone else; body is the current task" }, "400": { "description": "Neighbors not in the target column" }, "409": { "description": "Blocked by open tasks" }, "422": { "description": "Transition not allowed" } } } },
+    "/api/tasks/{id}/history": { "get": { "summary": "Task history, newest first: each revision has the actor, source api|scheduler|ai, field-level changes (from/to) and the resulting state (page, size)", "responses": { "200": { "description": "OK" } } } },
+    "/api/tasks/{id}/history/{revisionId}/restore": { "post": { "summary": "Restore the task to the state of a revision; same rules as PATCH, recorded as a new revision with restoredFrom", "responses": { "200": { "description": "OK" }, "404": { "description": "Revision not found" } } } },
+    "/api/tasks/{id}/restore": { "post": { "summary": "Restore task from the trash with the subtasks and comments deleted with it (owner); a status removed from the workflow meanwhile goes back to the initial one", "responses": { "200": { "description": "OK" }, "409": { "description": "Not in the trash, or parent still in the trash" } } } },
+    "/api/trash": { "get": { "summary": "Tasks in the trash I can restore, newest first, with purgeAt (page, size)", "responses": { "200": { "description": "OK" } } } },
+    "/api/board": { "get": { "summary": "Board: tasks grouped by the workflow statuses in manual order (projectId for a project board, viewer role; without it, my personal tasks)", "responses": { "200": { "description": "OK" } } } },
+    "/api/org": {
+      "get": { "summary": "My organization", "responses": { "200": { "description": "OK" } } },
+      "patch": { "summary": "Rename organization (org_admin)", "responses": { "200": { "description": "OK" } } }
+    },
+    "/api/org/users": {
+      "get": { "summary": "List users of my organization", "responses": { "200": { "description": "OK" } } },
+      "post": { "summary": "Create user in my organization with role user|org_admin (org_admin)", "responses": { "201": { "description": "Created" }, "409": { "description": "Email already registered" } } }
+    },
+    "/api/org/users/{id}": { "patch": { "summary": "Change user role (org_admin); the user's tokens and live connections are revoked immediately", "responses": { "200": { "description": "OK" }, "422": { "description": "Last org_admin" } } } },
+    "/api/admin/orgs": {
+      "get": { "summary": "List organizations (admin)", "responses": { "200": { "description": "OK" } } },
+      "post": { "summary": "Create organization (admin)", "responses": { "201": { "description": "Created" } } }
+    },
+    "/api/admin/orgs/{id}/users": { "post": { "summary": "Create user in an organization (admin)", "responses": { "201": { "description": "Created" } } } },
+    "/api/projects": {
+      "get": { "summary": "List projects I am a member of (org_admin sees the organization's, admin sees all)", "responses": { "200": { "description": "OK" } } },
+      "post": { "summary": "Create project; the creator becomes its owner", "responses": { "201": { "description": "Created" } } }
+    },
+    "/api/projects/{id}": {
+      "get": { "summary": "Get project with members", "responses": { "200": { "description": "OK" } } },
+      "patch": { "summary": "Rename or describe project (owner)", "responses": { "200": { "description": "OK" } } },
+      "delete": { "summary": "Delete project without tasks (owner); its tasks in the trash are purged", "responses": { "204": { "description": "No Content" }, "409": { "description": "Project still has tasks" } } }
+    },
+    "/api/projects/{id}/workflow": {
+      "get": { "summary": "Project workflow: ordered statuses with category not_started|in_progress|done and allowed transitions (null allows any)", "responses": { "200": { "description": "OK" } } },
+      "put": { "summary": "Replace project workflow (owner); statuses used by tasks cannot be removed", "responses": { "200": { "description": "OK" }, "409": { "description": "Statuses still in use" } } }
+    },
+    "/api/projects/{id}/members": { "post": { "summary": "Add member with role owner|editor|viewer (owner)", "responses": { "201": { "description": "Created" }, "409": { "description": "Already a member" } } } },
+    "/api/projects/{id}/members/{userId}": {
+      "patch": { "summary": "Change member role (owner)", "responses": { "200": { "description": "OK" }, "422": { "description": "Last owner" } } },
+      "delete": { "summary": "Remove member or leave the project", "responses": { "204": { "description": "No Content" }, "422": { "description": "Last owner" } } }
+    },
+    "/api/labels": {
+      "get": { "summary": "List my personal labels and the labels of projects I see (projectId=N for one project)", "responses": { "200": { "description": "OK" } } },
+      "post": { "summary": "Create label (personal, name unique per user; or projectId for a project label, needs editor role, name unique per project; color #rrggbb)", "responses": { "201": { "description": "Created" }, "409": { "description": "Name already used" } } }
+    },
+    "/api/labels/{id}": {
+      "patch": { "summary": "Rename or recolor label (my personal label, or a project label as project editor)", "responses": { "200": { "description": "OK" } } },
+      "delete": { "summary": "Delete label and remove it from tasks", "responses": { "204": { "description": "No Content" } } }
+    },
+    "/api/tasks/{id}/watch": {
+      "put": { "summary": "Watch task", "responses": { "204": { "description": "No Content" } } },
+      "delete": { "summary": "Stop watching task", "responses": { "204": { "description": "No Content" } } }
+    },
+    "/api/tasks/{id}/dependencies": {
+      "get": { "summary": "Upstream (blocked by) and downstream (blocks) dependency graph", "responses": { "200": { "description": "OK" } } },
+      "post": { "summary": "Mark task as blocked by blockerId", "responses": { "201": { "description": "Created" }, "422": { "description": "Would create a cycle" } } }
+    },
+    "/api/tasks/{id}/dependencies/{blockerId}": { "delete": { "summary": "Remove dependency", "responses": { "204": { "description": "No Content" } } } },
+    "/api/tasks/{id}/checklist": {
+      "get": { "summary": "List checklist items in order", "responses": { "200": { "description": "OK" } } },
+      "post": { "summary": "Add checklist item at the end", "responses": { "201": { "description": "Created" } } }
+    },
+    "/api/tasks/{id}/checklist/order": { "put": { "summary": "Reorder checklist (ids in the new order)", "responses": { "200": { "description": "OK" } } } },
+    "/api/tasks/{id}/checklist/{itemId}": {
+      "patch": { "summary": "Edit text or toggle done", "responses": { "200": { "description": "OK" } } },
+      "delete": { "summary": "Delete checklist item", "responses": { "204": { "description": "No Content" } } }
     },
     "/api/tasks/{id}/comments": {
       "get": { "summary": "List comments", "responses": { "200": { "description": "OK" } } },
       "post": { "summary": "Create comment", "responses": { "201": { "description": "Created" } } }
     },
-    "/ws": { "get": { "summary": "WebSocket", "responses": { "101": { "description": "Switching Protocols" } } } }
+    "/api/tasks/{id}/comments/{commentId}": { "delete": { "summary": "Move comment to the trash (author or task owner); purged after the trash retention", "responses": { "204": { "description": "No Content" }, "403": { "description": "Not the author or the task owner" } } } },
+    "/api/tasks/{id}/reminders": {
+      "get": { "summary": "List my reminders on the task", "responses": { "200": { "description": "OK" } } },
+      "post": { "summary": "Add reminder (at or offsetMinutes before due date)", "responses": { "201": { "description": "Created" } } }
+    },
+    "/api/tasks/{id}/reminders/{reminderId}": { "delete": { "summary": "Delete reminder", "responses": { "204": { "description": "No Content" } } } },
+    "/api/notifications": { "get": { "summary": "List notifications (cursor, limit, unread, type, taskId)", "responses": { "200": { "description": "OK" } } } },
+    "/api/notifications/unread-count": { "get": { "summary": "Unread notifications count", "responses": { "200": { "description": "OK" } } } },
+    "/api/notifications/read-all": { "post": { "summary": "Mark all notifications as read (optional before)", "responses": { "200": { "description": "OK" } } } },
+    "/api/notifications/{id}/read": { "patch": { "summary": "Mark notification as read", "responses": { "200": { "description": "OK" } } } },
+    "/api/notifications/{id}": { "delete": { "summary": "Dismiss notification", "responses": { "204": { "description": "No Content" } } } },
+    "/api/me/notification-preferences": {
+      "get": { "summary": "Get notification preferences", "responses": { "200": { "description": "OK" } } },
+      "put": { "summary": "Update notification preferences (types/channels, due-soon lead, quiet hours, timezone)", "responses": { "200": { "description": "OK" } } }
+    },
+    "/api/webhooks": {
+      "get": { "summary": "List my webhooks", "responses": { "200": { "description": "OK" } } },
+      "post": { "summary": "Register webhook (url, events); returns the signing secret once. The url must resolve to a public address", "responses": { "201": { "description": "Created" }, "400": { "description": "Invalid url, or loopback/private/link-local address" } } }
+    },
+    "/api/webhooks/{id}": {
+      "patch": { "summary": "Update or re-enable webhook", "responses": { "200": { "description": "OK" } } },
+      "delete": { "summary": "Delete webhook", "responses": { "204": { "description": "No Content" } } }
+    },
+    "/api/webhooks/{id}/deliveries": { "get": { "summary": "Delivery log (cursor, limit); only the response status is kept, not the body", "responses": { "200": { "description": "OK" } } } },
+    "/api/webhooks/{id}/deliveries/{deliveryId}/redeliver": { "post": { "summary": "Redeliver a past delivery", "responses": { "202": { "description": "Accepted" } } } },
+    "/api/chat-channels": {
+      "get": { "summary": "List my Slack/Mattermost channels and those of projects I own", "responses": { "200": { "description": "OK" } } },
+      "post": { "summary": "Add incoming-webhook channel (url, notifications, taskEvents; projectId makes it a project channel, project owners only)", "responses": { "201": { "description": "Created" } } }
+    },
+    "/api/chat-channels/{id}": {
+      "patch": { "summary": "Update chat channel", "responses": { "200": { "description": "OK" } } },
+      "delete": { "summary": "Delete chat channel", "responses": { "204": { "description": "No Content" } } }
+    },
+    "/api/chat-channels/{id}/test": { "post": { "summary": "Send a test message now", "responses": { "200": { "description": "OK" }, "502": { "description": "Delivery failed; only the chat service status code is returned" } } } },
+    "/api/admin/trash": {
+      "get": { "summary": "Trash retention in days (admin)", "responses": { "200": { "description": "OK" } } },
+      "put": { "summary": "Set trash retention, 1 to 3650 days; expired tasks are purged hourly by the leader replica with their comments, notifications and reminders (admin)", "responses": { "200": { "description": "OK" } } }
+    },
+    "/api/admin/scheduler": { "get": { "summary": "Scheduler leader and health (admin)", "responses": { "200": { "description": "OK" }, "503": { "description": "Unhealthy" } } } },
+    "/api/events": { "get": { "summary": "Server-Sent Events stream (supports Last-Event-ID)", "responses": { "200": { "description": "text/event-stream" } } } },
+    "/api/presence": { "get": { "summary": "Online users", "responses": { "200": { "description": "OK" } } } },
+    "/api/tasks/{id}/presence": { "get": { "summary": "Who is viewing/editing the task", "responses": { "200": { "description": "OK" } } } },
+    "/ws": { "get": { "summary": "WebSocket (auth: ?ticket= or Sec-WebSocket-Protocol: bearer, <jwt>)", "responses": { "101": { "description": "Switching Protocols" } } } }
   }
 }`
\ No newline at end of file
@@ -1,23 +1,36 @@
 package handlers
 
 import (
-	"time"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
+	"goTasks/internal/deps"
+	"goTasks/internal/history"
 	"goTasks/internal/models"
+	"goTasks/internal/policy"
+	"goTasks/internal/notify"
+	"goTasks/internal/recurrence"
 	"goTasks/internal/ws"
 )
 
+// errVersionConflict: a tarefa mudou entre a leitura e o UPDATE condicional
+var errVersionConflict = errors.New("task was changed by someone else")
+
 type TaskHandler struct {
-	db  *gorm.DB
-	hub *ws.Hub
+	db       *gorm.DB
+	hub      *ws.Hub
+	notifier *notify.Service
+	deps     *deps.Graph
 }
 
-func NewTaskHandler(db *gorm.DB, hub *ws.Hub) *TaskHandler {
-	return &TaskHandler{db: db, hub: hub}
+func NewTaskHandler(db *gorm.DB, hub *ws.Hub, notifier *notify.Service) *TaskHandler {
+	return &TaskHandler{db: db, hub: hub, notifier: notifier, deps: deps.New(db)}
 }
 
 func (h *TaskHandler) List(c *fiber.Ctx) error {
@@ -45,26 +58,84 @@ func (h *TaskHandler) List(c *fiber.Ctx) error {
 	userID := c.Locals("userID").(uint)
 	userRole, _ := c.Locals("userRole").(string)
 
-	qry := h.db.Preload("Owner").Order("created_at DESC")
+	order, err := parseSort(c.Query("sort"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	qry := h.db.Preload("Owner").Preload("Labels").Preload("Assignees").Preload("Watchers")
+	for _, o := range order {
+		qry = qry.Order(o)
+	}
 
 	// role-based scoping
-	if userRole != "admin" {
-		// usuário comum: restringe por owner
-		qry = qry.Where("owner_id = ?", userID)
+	if userRole != models.RoleAdmin {
+		// usuário comum: as que tem, as dos seus projetos, as atribuídas a ele e as que observa;
+		// org_admin: todas da organização
+		qry = qry.Where("id IN (?)", policy.VisibleTaskIDs(h.db, actor(c)))
 	} else {
-		// admin pode usar ownerId
+		// admin pode usar ownerId e orgId
 		if ownerID != "" {
 			qry = qry.Where("owner_id = ?", ownerID)
 		}
+		if org := c.Query("orgId"); org != "" {
+			qry = qry.Where("org_id = ?", org)
+		}
 	}
 	// me=true força owner_id = userID
 	if me {
 		qry = qry.Where("owner_id = ?", userID)
 	}
+	// assignee=me (ou um id) e watching=true
+	if assignee := c.Query("assignee"); assignee != "" {
+		var aid uint
+		if assignee == "me" {
+			aid = userID
+		} else if n := parseIntDefault(assignee, 0); n > 0 {
+			aid = uint(n)
+		} else {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "assignee must be me or a user id"})
+		}
+		qry = qry.Where("id IN (?)", h.db.Table(models.TaskAssigneesTable).Select("task_id").Where("user_id = ?", aid))
+	}
+	if c.QueryBool("watching") {
+		qry = qry.Where("id IN (?)", h.db.Table(models.TaskWatchersTable).Select("task_id").Where("user_id = ?", userID))
+	}
 
 	if status != "" {
 		qry = qry.Where("status = ?", status)
 	}
+	if category := c.Query("category"); category != "" {
+		qry = qry.Where("status_category = ?", category)
+	}
+	if parent := c.Query("parentId"); parent != "" {
+		qry = qry.Where("parent_id = ?", parent)
+	}
+	if project := c.Query("projectId"); project != "" {
+		qry = qry.Where("project_id = ?", project)
+	}
+	if p := c.Query("priority"); p != "" {
+		prio, err := models.ParsePriority(p)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		qry = qry.Where("priority = ?", prio)
+	}
+	// labels=a,b exige todas as etiquetas; anyLabel=a,b basta uma. Os nomes valem só entre
+	// as etiquetas que o usuário vê (as pessoais dele e as dos seus projetos)
+	if names := labelNames(c.Query("labels")); len(names) > 0 {
+		qry = qry.Where("id IN (?)", h.db.Table("task_labels").
+			Select("task_labels.task_id").
+			Joins("JOIN labels ON labels.id = task_labels.label_id").
+			Where("labels.name IN ? AND labels.id IN (?)", names, visibleLabelIDs(h.db, actor(c))).
+			Group("task_labels.task_id").
+			Having("COUNT(DISTINCT labels.name) = ?", len(names)))
+	}
+	if names := labelNames(c.Query("anyLabel")); len(names) > 0 {
+		qry = qry.Where("id IN (?)", h.db.Table("task_labels").
+			Select("task_labels.task_id").
+			Joins("JOIN labels ON labels.id = task_labels.label_id").
+			Where("labels.name IN ? AND labels.id IN (?)", names, visibleLabelIDs(h.db, actor(c))))
+	}
 	if q != "" {
 		like := "%" + q + "%"
 		qry = qry.Where("(title ILIKE ? OR description ILIKE ?)", like, like)
@@ -79,6 +150,9 @@ func (h *TaskHandler) List(c *fiber.Ctx) error {
 	if err := qry.Limit(size).Offset(offset).Find(&tasks).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
 	}
+	if err := loadProgress(h.db, tasks); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
 
 	return c.JSON(fiber.Map{
 		"items": tasks,
@@ -99,33 +173,139 @@ func (h *TaskHandler) Create(c *fiber.Ctx) error {
 		Title       string     `json:"title"`
 		Description string     `json:"description"`
 		Status      string     `json:"status"`
+		Priority    string     `json:"priority"`
 		DueDate     *time.Time `json:"dueDate"`
+		Recurrence  string     `json:"recurrence"` // RRULE, ex.: "FREQ=WEEKLY;BYDAY=MO"
+		LabelIDs    []uint     `json:"labelIds"`
+		ParentID    *uint      `json:"parentId"` // cria como subtarefa
+		ProjectID   *uint      `json:"projectId"`
+		AssigneeIDs []uint     `json:"assigneeIds"`
+		WatcherIDs  []uint     `json:"watcherIds"`
 	}
 	if err := c.BodyParser(&body); err != nil || body.Title == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid data"})
 	}
-	status := models.StatusTodo
+	ownerID, orgID := userID, actor(c).OrgID
+	if body.ProjectID != nil {
+		// criar no projeto exige papel de editor
+		if role, err := policy.OnProject(h.db, actor(c), *body.ProjectID); err != nil || role < policy.Editor || !projectInOrg(h.db, *body.ProjectID, orgID) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid project"})
+		}
+	}
+	projectID := body.ProjectID
+	if body.ParentID != nil {
+		// a subtarefa pertence ao dono da tarefa mãe
+		var parent models.Task
+		if err := h.db.First(&parent, "id = ?", *body.ParentID).Error; err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid parent"})
+		}
+		if role, err := policy.OnTask(h.db, actor(c), parent); err != nil || role < policy.Editor {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid parent"})
+		}
+		if err := checkParent(h.db, 0, parent.ID, 0); err != nil {
+			if errors.Is(err, errSubtaskDepth) {
+				return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+		}
+		ownerID, orgID = parent.OwnerID, parent.OrgID
+		projectID = parent.ProjectID
+	}
+	var rule *recurrence.Rule
+	if body.Recurrence != "" {
+		r, err := recurrence.Parse(body.Recurrence)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid recurrence: " + err.Error()})
+		}
+		if body.DueDate == nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": errRecurrenceNeedsDue.Error()})
+		}
+		rule = &r
+	}
+	// o status segue o workflow do projeto; sem status, a tarefa começa no primeiro
+	wf, err := workflowFor(h.db, projectID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	status := wf.Initial()
 	if body.Status != "" {
 		status = models.TaskStatus(body.Status)
 	}
-	task := models.Task{
-		Title:       body.Title,
-		Description: body.Description,
-		Status:      status,
-		DueDate:     body.DueDate,
-		OwnerID:     userID,
+	if _, ok := wf.Status(status); !ok {
+		return statusError(c, wf, "", status, false)
+	}
+	priority := models.PriorityNone
+	if body.Priority != "" {
+		p, err := models.ParsePriority(body.Priority)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		priority = p
+	}
+	labels, err := taskLabels(h.db, ownerID, projectID, body.LabelIDs)
+	if errors.Is(err, errInvalidLabels) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	assignees, ok := existingUsers(h.db, orgID, body.AssigneeIDs)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid assignees"})
+	}
+	watchers, ok := existingUsers(h.db, orgID, body.WatcherIDs)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid watchers"})
+	}
+	// a tarefa nova entra no fim da sua coluna do quadro
+	position, err := endOfColumn(h.db, boardColumn{ProjectID: projectID, OwnerID: ownerID, Status: status}, 0)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
 	}
-	if err := h.db.Create(&task).Error; err != nil {
+	task := models.Task{
+		Title:          body.Title,
+		Description:    body.Description,
+		Status:         status,
+		StatusCategory: wf.Category(status),
+		Priority:       priority,
+		DueDate:        body.DueDate,
+		OwnerID:        ownerID,
+		OrgID:          orgID,
+		Labels:         labels,
+		Assignees:      assignees,
+		Watchers:       watchers,
+		ParentID:       body.ParentID,
+		ProjectID:      projectID,
+		Position:       position,
+		Version:        1,
+	}
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if rule != nil {
+			if err := startSeries(tx, &task, *rule); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(&task).Error; err != nil {
+			return err
+		}
+		return history.Record(tx, task.ID, nil, history.By(userID))
+	})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
 	}
-	h.hub.Broadcast(ws.Event{Type: "task.created", Payload: task})
+	h.hub.Broadcast(ws.Event{Type: "task.created", Payload: task, To: policy.Audience(h.db, task), Org: task.OrgID})
+	h.notifier.AssigneesChanged(task, userIDs(assignees), nil, userID)
+	if task.ParentID != nil {
+		broadcastProgress(h.db, h.hub, *task.ParentID)
+	}
+	c.Set(fiber.HeaderETag, taskETag(task))
 	return c.Status(fiber.StatusCreated).JSON(task)
 }
 
 func (h *TaskHandler) GetByID(c *fiber.Ctx) error {
 	var task models.Task
 	id := c.Params("id")
-	if err := h.db.Preload("Owner").First(&task, "id = ?", id).Error; err != nil {
+	if err := h.db.Preload("Owner").Preload("Series").Preload("Labels").Preload("Assignees").Preload("Watchers").First(&task, "id = ?", id).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
 	}
 
@@ -134,12 +314,66 @@ func (h *TaskHandler) GetByID(c *fiber.Ctx) error {
 	if !ok || uid == 0 {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
 	}
-	userRole, _ := c.Locals("userRole").(string)
-	if userRole != "admin" && task.OwnerID != uid {
+	if role, err := policy.OnTask(h.db, actor(c), task); err != nil || role < policy.Viewer {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
 	}
+	tasks := []models.Task{task}
+	if err := loadProgress(h.db, tasks); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	c.Set(fiber.HeaderETag, taskETag(task))
+	return c.JSON(tasks[0])
+}
 
-	return c.JSON(task)
+// taskETag é a versão da tarefa no formato de ETag.
+func taskETag(task models.Task) string {
+	return fmt.Sprintf(`"%d"`, task.Version)
+}
+
+// ifMatch informa se o If-Match (ausente, * ou lista de ETags) aceita a versão atual.
+func ifMatch(c *fiber.Ctx, task models.Task) bool {
+	header := c.Get(fiber.HeaderIfMatch)
+	if header == "" {
+		return true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == taskETag(task) {
+			return true
+		}
+	}
+	return false
+}
+
+// conflict responde 412 com a representação atual, para o cliente refazer a edição sobre ela.
+func (h *TaskHandler) conflict(c *fiber.Ctx, id uint) error {
+	var current models.Task
+	if err := h.db.Preload("Owner").Preload("Series").Preload("Labels").Preload("Assignees").Preload("Watchers").First(&current, "id = ?", id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
+	}
+	tasks := []models.Task{current}
+	if err := loadProgress(h.db, tasks); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	c.Set(fiber.HeaderETag, taskETag(current))
+	return c.Status(fiber.StatusPreconditionFailed).JSON(tasks[0])
+}
+
+// taskPatch é o corpo do PATCH /tasks/:id; campo nil não muda.
+type taskPatch struct {
+	Title       *string    `json:"title"`
+	Description *string    `json:"description"`
+	Status      *string    `json:"status"`
+	Priority    *string    `json:"priority"`
+	DueDate     *time.Time `json:"dueDate"`
+	OwnerID     *uint      `json:"ownerId"`     // reatribuição (dono atual ou admin)
+	Recurrence  *string    `json:"recurrence"`  // "" encerra a série (com scope=future)
+	LabelIDs    *[]uint    `json:"labelIds"`    // substitui as etiquetas da tarefa
+	ParentID    *uint      `json:"parentId"`    // move para baixo de outra tarefa; 0 = vira raiz
+	AssigneeIDs *[]uint    `json:"assigneeIds"` // substitui os responsáveis (só o dono)
+	WatcherIDs  *[]uint    `json:"watcherIds"`  // substitui os observadores
+	ProjectID   *uint      `json:"projectId"`   // move para outro projeto; 0 = tira do projeto
+	clearDue    bool       // remove o vencimento (só a restauração de revisões usa)
 }
 
 func (h *TaskHandler) Update(c *fiber.Ctx) error {
@@ -154,39 +388,302 @@ func (h *TaskHandler) Update(c *fiber.Ctx) error {
 	if !ok || uid == 0 {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
 	}
-	userRole, _ := c.Locals("userRole").(string)
-	if userRole != "admin" && task.OwnerID != uid {
+	role, err := policy.OnTask(h.db, actor(c), task)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	if role == policy.None {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
+	}
+	if role < policy.Editor {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "not allowed"})
 	}
 
-	var body struct {
-		Title       *string     `json:"title"`
-		Description *string     `json:"description"`
-		Status      *string     `json:"status"`
-		DueDate     *time.Time  `json:"dueDate"`
-	}
+	var body taskPatch
 	if err := c.BodyParser(&body); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid data"})
 	}
+	// a origem vem do servidor, nunca do cliente: o que chega aqui é sempre uma edição via API
+	return h.patch(c, task, role, body, history.By(uid))
+}
+
+// patch aplica uma edição com as regras do PATCH (papéis, workflow, bloqueios, séries) e registra a revisão.
+func (h *TaskHandler) patch(c *fiber.Ctx, task models.Task, role policy.Role, body taskPatch, meta history.Meta) error {
+	uid := *meta.ActorID
+	if !ifMatch(c, task) {
+		return h.conflict(c, task.ID)
+	}
+	if role < policy.Owner && (body.OwnerID != nil || body.AssigneeIDs != nil || body.ProjectID != nil) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "only the owner can transfer the task, change assignees or move it between projects"})
+	}
+	if body.ProjectID != nil && *body.ProjectID != 0 {
+		if projectRole, err := policy.OnProject(h.db, actor(c), *body.ProjectID); err != nil || projectRole < policy.Editor || !projectInOrg(h.db, *body.ProjectID, task.OrgID) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid project"})
+		}
+	}
+	var assignees, watchers []models.User
+	if body.AssigneeIDs != nil {
+		var ok bool
+		if assignees, ok = existingUsers(h.db, task.OrgID, *body.AssigneeIDs); !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid assignees"})
+		}
+	}
+	if body.WatcherIDs != nil {
+		var ok bool
+		if watchers, ok = existingUsers(h.db, task.OrgID, *body.WatcherIDs); !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid watchers"})
+		}
+	}
+	prevAssignees, prevWatchers, err := models.TaskMembers(h.db, task.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	before, _, err := history.Take(h.db, task.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	// scope=future: em tarefa recorrente, a edição vale também para as próximas ocorrências
+	scope := c.Query("scope", ScopeThis)
+	if scope != ScopeThis && scope != ScopeFuture {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "scope must be this or future"})
+	}
+	var rule *recurrence.Rule
+	if body.Recurrence != nil {
+		if task.SeriesID != nil && scope != ScopeFuture {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "changing the recurrence requires scope=future"})
+		}
+		if *body.Recurrence != "" {
+			r, err := recurrence.Parse(*body.Recurrence)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid recurrence: " + err.Error()})
+			}
+			canonical := r.String()
+			body.Recurrence, rule = &canonical, &r
+		}
+	}
+	changes := map[string]interface{}{}
+	prevStatus, prevOwner, prevDue, prevParent := task.Status, task.OwnerID, task.DueDate, task.ParentID
+	prevCategory, prevProject, prevColumn := task.StatusCategory, task.ProjectID, columnOf(task)
+	prevAudience := policy.Audience(h.db, task, prevOwner)
+	if body.ProjectID != nil && *body.ProjectID == 0 {
+		task.ProjectID = nil
+	} else if body.ProjectID != nil {
+		task.ProjectID = body.ProjectID
+	}
+	if body.ParentID != nil && *body.ParentID == 0 {
+		task.ParentID = nil
+	} else if body.ParentID != nil {
+		var parent models.Task
+		if err := h.db.First(&parent, "id = ?", *body.ParentID).Error; err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid parent"})
+		}
+		if parentRole, err := policy.OnTask(h.db, actor(c), parent); err != nil || parentRole < policy.Editor || parent.OrgID != task.OrgID {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid parent"})
+		}
+		_, height, err := descendants(h.db, task.ID)
+		if err == nil {
+			err = checkParent(h.db, task.ID, parent.ID, height)
+		}
+		if errors.Is(err, errSubtaskDepth) || errors.Is(err, errSubtaskCycle) {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+		}
+		task.ParentID = &parent.ID
+	}
 	if body.Title != nil {
 		task.Title = *body.Title
+		changes["title"] = task.Title
 	}
 	if body.Description != nil {
 		task.Description = *body.Description
+		changes["description"] = task.Description
 	}
 	if body.Status != nil {
 		task.Status = models.TaskStatus(*body.Status)
 	}
+	if body.Priority != nil {
+		p, err := models.ParsePriority(*body.Priority)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		task.Priority = p
+	}
 	if body.DueDate != nil {
 		task.DueDate = body.DueDate
+	} else if body.clearDue {
+		task.DueDate = nil
+	}
+	if body.OwnerID != nil && *body.OwnerID != task.OwnerID {
+		var newOwner models.User
+		if err := h.db.Select("id").First(&newOwner, "id = ? AND org_id = ?", *body.OwnerID, task.OrgID).Error; err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid owner"})
+		}
+		task.OwnerID = newOwner.ID
+		task.Owner = models.User{}
+	}
+	var labels []models.Label
+	if body.LabelIDs != nil {
+		var err error
+		labels, err = taskLabels(h.db, task.OwnerID, task.ProjectID, *body.LabelIDs)
+		if errors.Is(err, errInvalidLabels) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+		}
+	}
+	// o status precisa existir no workflow do projeto e, sem troca de projeto, a transição precisa ser permitida
+	wf, err := workflowFor(h.db, task.ProjectID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	moved := !sameID(prevProject, task.ProjectID)
+	if _, ok := wf.Status(task.Status); !ok || (!moved && !wf.Allows(prevStatus, task.Status)) {
+		return statusError(c, wf, prevStatus, task.Status, moved)
+	}
+	task.StatusCategory = wf.Category(task.Status)
+	completed := task.StatusCategory == models.CategoryDone && prevCategory != models.CategoryDone
+	if completed {
+		if ok, err := h.guardBlockers(c, task.ID); !ok {
+			return err
+		}
+	}
+	// mudou de coluna no quadro (status, projeto ou dono de tarefa pessoal): vai para o fim da nova
+	// a posição só é gravada quando a tarefa troca de coluna: senão o valor lido aqui
+	// desfaria um renumber do quadro feito no meio tempo
+	omit := []string{clause.Associations, "position"}
+	if !columnOf(task).same(prevColumn) {
+		omit = omit[:1]
+		if task.Position, err = endOfColumn(h.db, columnOf(task), task.ID); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+		}
+	}
+	dueChanged := (body.DueDate != nil && (prevDue == nil || !prevDue.Equal(*body.DueDate))) || (body.clearDue && prevDue != nil)
+	inSeries := task.SeriesID != nil
+	var spawned *models.Task
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if !inSeries && rule != nil {
+			if err := startSeries(tx, &task, *rule); err != nil {
+				return err
+			}
+		}
+		// UPDATE condicional: se outra edição gravou depois da nossa leitura, nada muda e a resposta é 412
+		version := task.Version
+		task.Version++
+		res := tx.Model(&task).Where("version = ?", version).Select("*").Omit(omit...).Updates(&task)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errVersionConflict
+		}
+		if body.LabelIDs != nil {
+			if err := tx.Model(&task).Association("Labels").Replace(labels); err != nil {
+				return err
+			}
+		} else if moved {
+			// etiquetas de outro projeto não seguem a tarefa
+			stale := tx.Model(&models.Label{}).Select("id").Where("project_id IS NOT NULL")
+			if task.ProjectID != nil {
+				stale = stale.Where("project_id <> ?", *task.ProjectID)
+			}
+			if err := tx.Exec("DELETE FROM task_labels WHERE task_id = ? AND label_id IN (?)", task.ID, stale).Error; err != nil {
+				return err
+			}
+		}
+		if body.AssigneeIDs != nil {
+			if err := tx.Model(&task).Association("Assignees").Replace(assignees); err != nil {
+				return err
+			}
+		}
+		if body.WatcherIDs != nil {
+			if err := tx.Model(&task).Association("Watchers").Replace(watchers); err != nil {
+				return err
+			}
+		}
+		if inSeries && scope == ScopeFuture {
+			if err := updateSeries(tx, task, changes, body.Recurrence, dueChanged, meta); err != nil {
+				return err
+			}
+		}
+		if err := history.Record(tx, task.ID, &before, meta); err != nil {
+			return err
+		}
+		// a próxima ocorrência nasce junto com a conclusão: se falhar, a edição toda volta
+		if completed {
+			var err error
+			spawned, err = spawnNext(tx, task)
+			return err
+		}
+		return nil
+	})
+	if errors.Is(err, errRecurrenceNeedsDue) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if errors.Is(err, errVersionConflict) {
+		return h.conflict(c, task.ID)
 	}
-	if err := h.db.Save(&task).Error; err != nil {
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
 	}
-	h.hub.Broadcast(ws.Event{Type: "task.updated", Payload: task})
+	// o payload de task.updated leva sempre etiquetas, responsáveis e observadores atuais
+	var current models.Task
+	if err := h.db.Preload("Labels").Preload("Assignees").Preload("Watchers").First(&current, "id = ?", task.ID).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	task.Labels, task.Assignees, task.Watchers = current.Labels, current.Assignees, current.Watchers
+	task.Position = current.Position
+	if dueChanged {
+		if err := notify.RescheduleReminders(h.db, task.ID, task.DueDate); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+		}
+	}
+	// quem saiu da tarefa também recebe o evento, para tirá-la da tela
+	audience := append(append(prevAudience, prevAssignees...), prevWatchers...)
+	h.hub.Broadcast(ws.Event{Type: "task.updated", Payload: task, To: policy.Audience(h.db, task, audience...), Org: task.OrgID})
+	if body.AssigneeIDs != nil {
+		added, removed := diffIDs(prevAssignees, userIDs(task.Assignees))
+		if len(added) > 0 || len(removed) > 0 {
+			h.hub.Broadcast(ws.Event{Type: "task.assignees.changed", Payload: fiber.Map{"taskId": task.ID, "assignees": task.Assignees, "added": added, "removed": removed}, To: policy.Audience(h.db, task, removed...), Org: task.OrgID})
+			h.notifier.AssigneesChanged(task, added, removed, uid)
+		}
+	}
+	// o progresso da mãe muda com o status da subtarefa ou quando ela troca de mãe
+	reparented := !sameID(prevParent, task.ParentID)
+	if task.ParentID != nil && (reparented || task.Status != prevStatus) {
+		broadcastProgress(h.db, h.hub, *task.ParentID)
+	}
+	if prevParent != nil && reparented {
+		broadcastProgress(h.db, h.hub, *prevParent)
+	}
+	if task.OwnerID != prevOwner {
+		h.notifier.Reassigned(task, prevOwner, uid)
+	}
+	if task.Status != prevStatus {
+		h.notifier.StatusChanged(task, prevStatus, uid)
+	}
+	if spawned != nil {
+		h.hub.Broadcast(ws.Event{Type: "task.created", Payload: *spawned, To: policy.Audience(h.db, *spawned), Org: spawned.OrgID})
+	}
+	c.Set(fiber.HeaderETag, taskETag(task))
 	return c.JSON(task)
 }
 
+// guardBlockers responde 409 se a tarefa ainda tem bloqueios abertos;
+// só um admin pode concluir passando por cima deles (force=true)
+func (h *TaskHandler) guardBlockers(c *fiber.Ctx, taskID uint) (bool, error) {
+	blockers, err := h.deps.OpenBlockers(taskID)
+	if err != nil {
+		return false, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	if len(blockers) > 0 && !(c.QueryBool("force") && actor(c).Admin) {
+		return false, c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "task is blocked by open tasks", "blockers": blockers})
+	}
+	return true, nil
+}
+
 func (h *TaskHandler) Delete(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var task models.Task
@@ -199,15 +696,55 @@ func (h *TaskHandler) Delete(c *fiber.Ctx) error {
 	if !ok || uid == 0 {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
 	}
-	userRole, _ := c.Locals("userRole").(string)
-	if userRole != "admin" && task.OwnerID != uid {
+	if can, err := policy.Can(h.db, actor(c), task, policy.Owner); err != nil || !can {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "not allowed"})
 	}
 
-	if err := h.db.Delete(&models.Task{}, "id = ?", id).Error; err != nil {
+	audience := policy.Audience(h.db, task)
+	// subtarefas vão junto para a lixeira
+	subtasks, _, err := descendants(h.db, task.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	if err := h.db.Transaction(func(tx *gorm.DB) error { return trashTaskTree(tx, task.ID, time.Now().UTC().Truncate(time.Microsecond)) }); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	h.hub.Broadcast(ws.Event{Type: "task.deleted", Payload: fiber.Map{"id": id, "subtasks": subtasks, "projectId": task.ProjectID}, To: audience, Org: task.OrgID})
+	if task.ParentID != nil {
+		broadcastProgress(h.db, h.hub, *task.ParentID)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Watch passa a observar a tarefa (quem já pode vê-la).
+func (h *TaskHandler) Watch(c *fiber.Ctx) error {
+	return h.setWatching(c, true)
+}
+
+// Unwatch deixa de observar a tarefa.
+func (h *TaskHandler) Unwatch(c *fiber.Ctx) error {
+	return h.setWatching(c, false)
+}
+
+func (h *TaskHandler) setWatching(c *fiber.Ctx, watch bool) error {
+	var task models.Task
+	if err := h.db.First(&task, "id = ?", c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
+	}
+	uid, _ := c.Locals("userID").(uint)
+	if role, err := policy.OnTask(h.db, actor(c), task); err != nil || role < policy.Viewer {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
+	}
+	var err error
+	if watch {
+		err = h.db.Clauses(clause.OnConflict{DoNothing: true}).Table(models.TaskWatchersTable).
+			Create(map[string]interface{}{"task_id": task.ID, "user_id": uid}).Error
+	} else {
+		err = h.db.Exec("DELETE FROM "+models.TaskWatchersTable+" WHERE task_id = ? AND user_id = ?", task.ID, uid).Error
+	}
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
 	}
-	h.hub.Broadcast(ws.Event{Type: "task.deleted", Payload: fiber.Map{"id": id}})
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
@@ -0,0 +1,1033 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"goTasks/internal/models"
+	"goTasks/internal/notify"
+	"goTasks/internal/ws"
+)
+
+type testEnv struct {
+	t   *testing.T
+	db  *gorm.DB
+	app *fiber.App
+	hub *ws.Hub
+}
+
+// newTestEnv monta as rotas de tarefas com um "JWT" de teste: X-User, X-Role e X-Org viram os Locals.
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&models.User{}, &models.TaskSeries{}, &models.Task{}, &models.Comment{},
+		&models.Notification{}, &models.NotificationPreference{}, &models.TaskReminder{}, &models.Label{},
+		&models.ChecklistItem{}, &models.TaskDependency{}, &models.Project{}, &models.ProjectMember{}, &models.Organization{}, &models.Workflow{},
+		&models.TaskRevision{}, &models.Setting{}); err != nil {
+		t.Fatal(err)
+	}
+	hub := ws.NewHub()
+	go hub.Run()
+	notifier := notify.NewService(db, hub, nil, "http://app")
+	tasks := NewTaskHandler(db, hub, notifier)
+
+	app := fiber.New()
+	api := app.Group("/api", func(c *fiber.Ctx) error {
+		var uid uint
+		json.Unmarshal([]byte(c.Get("X-User")), &uid)
+		c.Locals("userID", uid)
+		c.Locals("userRole", c.Get("X-Role", "user"))
+		var org uint
+		json.Unmarshal([]byte(c.Get("X-Org", "0")), &org)
+		c.Locals("orgID", org)
+		return c.Next()
+	})
+	api.Get("/tasks", tasks.List)
+	api.Post("/tasks", tasks.Create)
+	api.Get("/tasks/:id", tasks.GetByID)
+	api.Patch("/tasks/:id", tasks.Update)
+	api.Delete("/tasks/:id", tasks.Delete)
+	api.Post("/tasks/:id/move", tasks.Move)
+	api.Get("/tasks/:id/history", tasks.History)
+	api.Post("/tasks/:id/restore", tasks.RestoreFromTrash)
+	api.Get("/trash", tasks.Trash)
+	api.Post("/tasks/:id/history/:revisionId/restore", tasks.Restore)
+	api.Get("/board", tasks.Board)
+	api.Delete("/tasks/:id/watch", tasks.Unwatch)
+	checklist := NewChecklistHandler(db, hub)
+	api.Post("/tasks/:id/checklist", checklist.Create)
+	api.Put("/tasks/:id/checklist/order", checklist.Reorder)
+	api.Patch("/tasks/:id/checklist/:itemId", checklist.Update)
+	dependencies := NewDependencyHandler(db, hub)
+	api.Get("/tasks/:id/dependencies", dependencies.List)
+	api.Post("/tasks/:id/dependencies", dependencies.Add)
+	comments := NewCommentHandler(db, hub, notifier)
+	api.Post("/tasks/:id/comments", comments.CreateOnTask)
+	projects := NewProjectHandler(db, hub)
+	api.Post("/projects", projects.Create)
+	api.Delete("/projects/:id", projects.Delete)
+	api.Post("/projects/:id/members", projects.AddMember)
+	api.Patch("/projects/:id/members/:userId", projects.UpdateMember)
+	labels := NewLabelHandler(db)
+	api.Post("/labels", labels.Create)
+	api.Delete("/labels/:id", labels.Delete)
+	api.Get("/tasks/:id/comments", comments.ListByTask)
+	api.Delete("/tasks/:id/comments/:commentId", comments.Delete)
+	api.Get("/projects", projects.List)
+	workflows := NewWorkflowHandler(db, hub)
+	api.Put("/projects/:id/workflow", workflows.Put)
+	orgs := NewOrgHandler(db, hub)
+	api.Get("/org/users", orgs.Users)
+	api.Post("/org/users", orgs.AddUser)
+	api.Patch("/org/users/:id", orgs.UpdateUser)
+	return &testEnv{t: t, db: db, app: app, hub: hub}
+}
+
+func (e *testEnv) user(name, role string) models.User {
+	return e.userIn(name, role, 0)
+}
+
+func (e *testEnv) userIn(name, role string, orgID uint) models.User {
+	u := models.User{Name: name, Email: name + "@example.com", Role: role, OrgID: orgID}
+	if err := e.db.Create(&u).Error; err != nil {
+		e.t.Fatal(err)
+	}
+	return u
+}
+
+// do faz a requisição como o usuário e decodifica a resposta em out (se não for nil).
+func (e *testEnv) do(method, path string, as models.User, body interface{}, out interface{}) int {
+	e.t.Helper()
+	code, _ := e.doWith(method, path, as, nil, body, out)
+	return code
+}
+
+// doWith é o do com cabeçalhos extras na requisição e os da resposta de volta
+func (e *testEnv) doWith(method, path string, as models.User, headers map[string]string, body interface{}, out interface{}) (int, http.Header) {
+	e.t.Helper()
+	var r io.Reader
+	if body != nil {
+		b, _ := json.Marshal(body)
+		r = bytes.NewReader(b)
+	}
+	req := httptest.NewRequest(method, path, r)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User", itoa(as.ID))
+	req.Header.Set("X-Role", as.Role)
+	req.Header.Set("X-Org", itoa(as.OrgID))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.app.Test(req, -1)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		json.NewDecoder(resp.Body).Decode(out)
+	}
+	return resp.StatusCode, resp.Header
+}
+
+func TestRecurringTaskSpawnsNextOccurrence(t *testing.T) {
+	e := newTestEnv(t)
+	ana := e.user("ana", "user")
+	due := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC) // segunda
+
+	var first models.Task
+	if code := e.do("POST", "/api/tasks", ana, fiber.Map{"title": "Backup", "dueDate": due, "recurrence": "FREQ=WEEKLY;BYDAY=MO,TH;COUNT=3"}, &first); code != 201 {
+		t.Fatalf("create: %d", code)
+	}
+	if first.SeriesID == nil || first.Occurrence != 1 {
+		t.Fatalf("expected first occurrence of a series, got %+v", first)
+	}
+	if code := e.do("POST", "/api/tasks", ana, fiber.Map{"title": "x", "recurrence": "FREQ=DAILY"}, nil); code != 400 {
+		t.Fatalf("recurrence without dueDate must be rejected, got %d", code)
+	}
+
+	// "esta e as futuras": muda o título do modelo da série
+	if code := e.do("PATCH", "/api/tasks/"+itoa(first.ID)+"?scope=future", ana, fiber.Map{"title": "Backup semanal"}, nil); code != 200 {
+		t.Fatalf("update future: %d", code)
+	}
+	if code := e.do("PATCH", "/api/tasks/"+itoa(first.ID), ana, fiber.Map{"recurrence": "FREQ=DAILY"}, nil); code != 400 {
+		t.Fatalf("changing the rule needs scope=future, got %d", code)
+	}
+
+	done := func(id uint) {
+		t.Helper()
+		if code := e.do("PATCH", "/api/tasks/"+itoa(id), ana, fiber.Map{"status": "done"}, nil); code != 200 {
+			t.Fatalf("done %d: %d", id, code)
+		}
+	}
+	next := func(occurrence int) (models.Task, bool) {
+		var task models.Task
+		err := e.db.Where("series_id = ? AND occurrence = ?", *first.SeriesID, occurrence).First(&task).Error
+		return task, err == nil
+	}
+
+	done(first.ID)
+	done(first.ID) // concluir de novo não duplica
+	second, ok := next(2)
+	if !ok || second.Title != "Backup semanal" || !second.DueDate.Equal(due.AddDate(0, 0, 3)) || second.Status != models.StatusTodo {
+		t.Fatalf("unexpected second occurrence: %+v", second)
+	}
+	done(second.ID)
+	third, ok := next(3)
+	if !ok || !third.DueDate.Equal(due.AddDate(0, 0, 7)) {
+		t.Fatalf("unexpected third occurrence: %+v", third)
+	}
+	done(third.ID)
+	if _, ok := next(4); ok {
+		t.Fatalf("COUNT=3 must stop the series")
+	}
+
+	// sem a próxima ocorrência a conclusão não vale: PATCH e quadro respondem 500 e nada muda
+	var broken models.Task
+	if code := e.do("POST", "/api/tasks", ana, fiber.Map{"title": "Relatório", "dueDate": due, "recurrence": "FREQ=DAILY"}, &broken); code != 201 {
+		t.Fatalf("create: %d", code)
+	}
+	e.db.Model(&models.TaskSeries{}).Where("id = ?", *broken.SeriesID).Update("rrule", "invalid")
+	if code := e.do("PATCH", "/api/tasks/"+itoa(broken.ID), ana, fiber.Map{"status": "done"}, nil); code != 500 {
+		t.Fatalf("a failed next occurrence must fail the edit, got %d", code)
+	}
+	if code := e.do("POST", "/api/tasks/"+itoa(broken.ID)+"/move", ana, fiber.Map{"status": "done"}, nil); code != 500 {
+		t.Fatalf("a failed next occurrence must fail the move, got %d", code)
+	}
+	var reloaded models.Task
+	e.db.First(&reloaded, broken.ID)
+	if reloaded.Status != models.StatusTodo || reloaded.Version != broken.Version {
+		t.Fatalf("the task must stay open when the next occurrence fails, got %+v", reloaded)
+	}
+}
+
+func TestListSortsByPriorityWithDueDateNullsLast(t *testing.T) {
+	e := newTestEnv(t)
+	ana := e.user("ana", "user")
+	day := func(d int) time.Time { return time.Date(2026, 5, d, 12, 0, 0, 0, time.UTC) }
+	for _, body := range []fiber.Map{
+		{"title": "sem prazo", "priority": "high"},
+		{"title": "alta tarde", "priority": "high", "dueDate": day(20)},
+		{"title": "baixa", "priority": "low", "dueDate": day(1)},
+		{"title": "alta cedo", "priority": "high", "dueDate": day(10)},
+	} {
+		if code := e.do("POST", "/api/tasks", ana, body, nil); code != 201 {
+			t.Fatalf("create %v: %d", body["title"], code)
+		}
+	}
+	if code := e.do("POST", "/api/tasks", ana, fiber.Map{"title": "x", "priority": "critical"}, nil); code != 400 {
+		t.Fatalf("unknown priority must be rejected, got %d", code)
+	}
+
+	var out struct {
+		Items []models.Task `json:"items"`
+	}
+	if code := e.do("GET", "/api/tasks?sort=-priority,dueDate", ana, nil, &out); code != 200 {
+		t.Fatalf("list: %d", code)
+	}
+	var titles []string
+	for _, task := range out.Items {
+		titles = append(titles, task.Title)
+	}
+	want := []string{"alta cedo", "alta tarde", "sem prazo", "baixa"}
+	if len(titles) != len(want) {
+		t.Fatalf("got %v, want %v", titles, want)
+	}
+	for i := range want {
+		if titles[i] != want[i] {
+			t.Fatalf("got %v, want %v", titles, want)
+		}
+	}
+	if code := e.do("GET", "/api/tasks?sort=owner_id", ana, nil, nil); code != 400 {
+		t.Fatalf("sort outside the whitelist must be rejected, got %d", code)
+	}
+}
+
+func TestListFiltersByLabels(t *testing.T) {
+	e := newTestEnv(t)
+	ana := e.user("ana", "user")
+	bob := e.user("bob", "user")
+	label := func(as models.User, name string) models.Label {
+		var l models.Label
+		if code := e.do("POST", "/api/labels", as, fiber.Map{"name": name}, &l); code != 201 {
+			t.Fatalf("create label %s: %d", name, code)
+		}
+		return l
+	}
+	bug, urgent := label(ana, "bug"), label(ana, "urgente")
+	other := label(bob, "bug")
+	if code := e.do("POST", "/api/labels", ana, fiber.Map{"name": "bug"}, nil); code != 409 {
+		t.Fatalf("duplicate label name must conflict, got %d", code)
+	}
+
+	var both, onlyBug models.Task
+	e.do("POST", "/api/tasks", ana, fiber.Map{"title": "ambas", "labelIds": []uint{bug.ID, urgent.ID}}, &both)
+	e.do("POST", "/api/tasks", ana, fiber.Map{"title": "só bug", "labelIds": []uint{bug.ID}}, &onlyBug)
+	e.do("POST", "/api/tasks", ana, fiber.Map{"title": "sem etiqueta"}, nil)
+	if len(both.Labels) != 2 {
+		t.Fatalf("created task must carry its labels, got %+v", both.Labels)
+	}
+	if code := e.do("POST", "/api/tasks", ana, fiber.Map{"title": "x", "labelIds": []uint{other.ID}}, nil); code != 400 {
+		t.Fatalf("labels of another user must be rejected, got %d", code)
+	}
+
+	titles := func(query string) []string {
+		t.Helper()
+		var out struct {
+			Items []models.Task `json:"items"`
+		}
+		if code := e.do("GET", "/api/tasks?sort=title&"+query, ana, nil, &out); code != 200 {
+			t.Fatalf("list %s: %d", query, code)
+		}
+		var ts []string
+		for _, task := range out.Items {
+			ts = append(ts, task.Title)
+		}
+		return ts
+	}
+	if got := titles("labels=bug,urgente"); len(got) != 1 || got[0] != "ambas" {
+		t.Fatalf("labels= must require all labels, got %v", got)
+	}
+	if got := titles("anyLabel=bug,urgente"); len(got) != 2 {
+		t.Fatalf("anyLabel= must match any label, got %v", got)
+	}
+
+	// etiqueta de projeto: os editores gerem, as tarefas do projeto usam, e o nome não colide com os pessoais
+	caio := e.user("caio", "user")
+	var project models.Project
+	if code := e.do("POST", "/api/projects", ana, fiber.Map{"name": "Site"}, &project); code != 201 {
+		t.Fatalf("create project: %d", code)
+	}
+	members := "/api/projects/" + itoa(project.ID) + "/members"
+	e.do("POST", members, ana, fiber.Map{"userId": bob.ID, "role": "editor"}, nil)
+	e.do("POST", members, ana, fiber.Map{"userId": caio.ID, "role": "viewer"}, nil)
+	var shared models.Label
+	if code := e.do("POST", "/api/labels", bob, fiber.Map{"name": "bug", "projectId": project.ID}, &shared); code != 201 {
+		t.Fatalf("project editor create label: %d", code)
+	}
+	if code := e.do("POST", "/api/labels", caio, fiber.Map{"name": "infra", "projectId": project.ID}, nil); code != 403 {
+		t.Fatalf("project viewer must not create labels, got %d", code)
+	}
+	if code := e.do("POST", "/api/labels", ana, fiber.Map{"name": "bug", "projectId": project.ID}, nil); code != 409 {
+		t.Fatalf("duplicate project label name must conflict, got %d", code)
+	}
+	if code := e.do("POST", "/api/tasks", ana, fiber.Map{"title": "no projeto", "projectId": project.ID, "labelIds": []uint{shared.ID}}, nil); code != 201 {
+		t.Fatalf("project task with project label: %d", code)
+	}
+	if code := e.do("POST", "/api/tasks", ana, fiber.Map{"title": "x", "labelIds": []uint{shared.ID}}, nil); code != 400 {
+		t.Fatalf("project labels must stay in the project, got %d", code)
+	}
+	// o filtro só conta etiquetas que ana vê: a "bug" pessoal de bob numa tarefa que ela observa fica de fora
+	if code := e.do("POST", "/api/tasks", bob, fiber.Map{"title": "de bob", "labelIds": []uint{other.ID}, "watcherIds": []uint{ana.ID}}, nil); code != 201 {
+		t.Fatalf("create: %d", code)
+	}
+	if got := fmt.Sprint(titles("anyLabel=bug")); got != "[ambas no projeto só bug]" {
+		t.Fatalf("label filters must only match labels the caller sees, got %s", got)
+	}
+
+	var updated models.Task
+	if code := e.do("PATCH", "/api/tasks/"+itoa(onlyBug.ID), ana, fiber.Map{"labelIds": []uint{urgent.ID}}, &updated); code != 200 {
+		t.Fatalf("update labels: %d", code)
+	}
+	if len(updated.Labels) != 1 || updated.Labels[0].Name != "urgente" {
+		t.Fatalf("labels must be replaced, got %+v", updated.Labels)
+	}
+	if code := e.do("DELETE", "/api/labels/"+itoa(urgent.ID), ana, nil, nil); code != 204 {
+		t.Fatalf("delete label: %d", code)
+	}
+	if got := titles("anyLabel=urgente"); len(got) != 0 {
+		t.Fatalf("deleted label must leave the tasks, got %v", got)
+	}
+}
+
+func TestSubtasksAndChecklistProgress(t *testing.T) {
+	e := newTestEnv(t)
+	ana := e.user("ana", "user")
+	create := func(title string, parent uint) (models.Task, int) {
+		var task models.Task
+		body := fiber.Map{"title": title}
+		if parent != 0 {
+			body["parentId"] = parent
+		}
+		code := e.do("POST", "/api/tasks", ana, body, &task)
+		return task, code
+	}
+	root, _ := create("raiz", 0)
+	a, _ := create("a", root.ID)
+	create("b", root.ID)
+	a1, _ := create("a1", a.ID)
+	a2, _ := create("a2", a1.ID)
+	if _, code := create("fundo demais", a2.ID); code != 422 {
+		t.Fatalf("depth limit must be enforced, got %d", code)
+	}
+	if code := e.do("PATCH", "/api/tasks/"+itoa(a.ID), ana, fiber.Map{"parentId": a1.ID}, nil); code != 422 {
+		t.Fatalf("moving a task under its own subtask must be rejected, got %d", code)
+	}
+	e.do("PATCH", "/api/tasks/"+itoa(a.ID), ana, fiber.Map{"status": "done"}, nil)
+
+	var items [3]models.ChecklistItem
+	for i, text := range []string{"um", "dois", "três"} {
+		if code := e.do("POST", "/api/tasks/"+itoa(root.ID)+"/checklist", ana, fiber.Map{"text": text}, &items[i]); code != 201 {
+			t.Fatalf("add item: %d", code)
+		}
+	}
+	e.do("PATCH", "/api/tasks/"+itoa(root.ID)+"/checklist/"+itoa(items[1].ID), ana, fiber.Map{"done": true}, nil)
+	var ordered []models.ChecklistItem
+	if code := e.do("PUT", "/api/tasks/"+itoa(root.ID)+"/checklist/order", ana, fiber.Map{"ids": []uint{items[2].ID, items[0].ID, items[1].ID}}, &ordered); code != 200 {
+		t.Fatalf("reorder: %d", code)
+	}
+	if len(ordered) != 3 || ordered[0].Text != "três" || ordered[2].Text != "dois" {
+		t.Fatalf("unexpected order: %+v", ordered)
+	}
+
+	var got models.Task
+	e.do("GET", "/api/tasks/"+itoa(root.ID), ana, nil, &got)
+	want := models.TaskProgress{Subtasks: models.Progress{Done: 1, Total: 2}, Checklist: models.Progress{Done: 1, Total: 3}}
+	if got.Progress == nil || *got.Progress != want {
+		t.Fatalf("got progress %+v, want %+v", got.Progress, want)
+	}
+
+	if code := e.do("DELETE", "/api/tasks/"+itoa(a.ID), ana, nil, nil); code != 204 {
+		t.Fatalf("delete: %d", code)
+	}
+	var left int64
+	e.db.Model(&models.Task{}).Where("id IN ?", []uint{a.ID, a1.ID, a2.ID}).Count(&left)
+	if left != 0 {
+		t.Fatalf("deleting a task must delete its subtasks, %d left", left)
+	}
+}
+
+func TestBlockedTaskCannotBeDone(t *testing.T) {
+	e := newTestEnv(t)
+	ana := e.user("ana", "user")
+	admin := e.user("root", "admin")
+	var blocker, task models.Task
+	e.do("POST", "/api/tasks", ana, fiber.Map{"title": "migrar banco"}, &blocker)
+	e.do("POST", "/api/tasks", ana, fiber.Map{"title": "deploy"}, &task)
+	if code := e.do("POST", "/api/tasks/"+itoa(task.ID)+"/dependencies", ana, fiber.Map{"blockerId": blocker.ID}, nil); code != 201 {
+		t.Fatalf("add dependency: %d", code)
+	}
+	if code := e.do("POST", "/api/tasks/"+itoa(blocker.ID)+"/dependencies", ana, fiber.Map{"blockerId": task.ID}, nil); code != 422 {
+		t.Fatalf("cycle must be rejected, got %d", code)
+	}
+
+	var graph struct {
+		Upstream struct {
+			Nodes []models.Task           `json:"nodes"`
+			Edges []models.TaskDependency `json:"edges"`
+		} `json:"upstream"`
+	}
+	e.do("GET", "/api/tasks/"+itoa(task.ID)+"/dependencies", ana, nil, &graph)
+	if len(graph.Upstream.Nodes) != 1 || graph.Upstream.Nodes[0].ID != blocker.ID || len(graph.Upstream.Edges) != 1 {
+		t.Fatalf("unexpected upstream graph: %+v", graph.Upstream)
+	}
+
+	done := fiber.Map{"status": "done"}
+	if code := e.do("PATCH", "/api/tasks/"+itoa(task.ID)+"?force=true", ana, done, nil); code != 409 {
+		t.Fatalf("only admins can force, got %d", code)
+	}
+	if code := e.do("PATCH", "/api/tasks/"+itoa(task.ID)+"?force=true", admin, done, nil); code != 200 {
+		t.Fatalf("admin force: %d", code)
+	}
+	var other models.Task
+	e.do("POST", "/api/tasks", ana, fiber.Map{"title": "anunciar"}, &other)
+	e.do("POST", "/api/tasks/"+itoa(other.ID)+"/dependencies", ana, fiber.Map{"blockerId": blocker.ID}, nil)
+	if code := e.do("PATCH", "/api/tasks/"+itoa(other.ID), ana, done, nil); code != 409 {
+		t.Fatalf("open blocker must prevent done, got %d", code)
+	}
+	e.do("PATCH", "/api/tasks/"+itoa(blocker.ID), ana, done, nil)
+	if code := e.do("PATCH", "/api/tasks/"+itoa(other.ID), ana, done, nil); code != 200 {
+		t.Fatalf("done after blocker closed: %d", code)
+	}
+}
+
+func TestAssigneesAndWatchersAccess(t *testing.T) {
+	e := newTestEnv(t)
+	ana, bia, caio, duda := e.user("ana", "user"), e.user("bia", "user"), e.user("caio", "user"), e.user("duda", "user")
+	var task models.Task
+	if code := e.do("POST", "/api/tasks", ana, fiber.Map{"title": "relatório", "assigneeIds": []uint{bia.ID}, "watcherIds": []uint{caio.ID}}, &task); code != 201 {
+		t.Fatalf("create: %d", code)
+	}
+	var n int64
+	e.db.Model(&models.Notification{}).Where("user_id = ? AND type = ?", bia.ID, models.NotificationAssigned).Count(&n)
+	if n != 1 {
+		t.Fatalf("assignee must be notified, got %d", n)
+	}
+
+	path := "/api/tasks/" + itoa(task.ID)
+	for _, u := range []models.User{bia, caio} {
+		if code := e.do("GET", path, u, nil, nil); code != 200 {
+			t.Fatalf("%s must see the task, got %d", u.Name, code)
+		}
+	}
+	if code := e.do("GET", path, duda, nil, nil); code != 404 {
+		t.Fatalf("outsider must not see the task, got %d", code)
+	}
+	if code := e.do("PATCH", path, bia, fiber.Map{"status": "doing"}, nil); code != 200 {
+		t.Fatalf("assignee update: %d", code)
+	}
+	if code := e.do("PATCH", path, bia, fiber.Map{"assigneeIds": []uint{bia.ID, duda.ID}}, nil); code != 403 {
+		t.Fatalf("only the owner changes assignees, got %d", code)
+	}
+	if code := e.do("PATCH", path, caio, fiber.Map{"status": "done"}, nil); code != 403 {
+		t.Fatalf("watcher must not update, got %d", code)
+	}
+	if code := e.do("DELETE", path, bia, nil, nil); code != 403 {
+		t.Fatalf("assignee must not delete, got %d", code)
+	}
+
+	count := func(as models.User, query string) int {
+		t.Helper()
+		var out struct {
+			Items []models.Task `json:"items"`
+		}
+		if code := e.do("GET", "/api/tasks?"+query, as, nil, &out); code != 200 {
+			t.Fatalf("list %s: %d", query, code)
+		}
+		return len(out.Items)
+	}
+	if count(bia, "assignee=me") != 1 || count(caio, "assignee=me") != 0 || count(caio, "watching=true") != 1 {
+		t.Fatal("assignee=me / watching=true filters do not match")
+	}
+	if code := e.do("DELETE", path+"/watch", caio, nil, nil); code != 204 {
+		t.Fatalf("unwatch: %d", code)
+	}
+	if count(caio, "") != 0 {
+		t.Fatal("after unwatching the task must leave the list")
+	}
+
+	var updated models.Task
+	e.do("PATCH", path, ana, fiber.Map{"assigneeIds": []uint{duda.ID}}, &updated)
+	if len(updated.Assignees) != 1 || updated.Assignees[0].ID != duda.ID {
+		t.Fatalf("assignees must be replaced, got %+v", updated.Assignees)
+	}
+	if code := e.do("GET", path, bia, nil, nil); code != 404 {
+		t.Fatalf("removed assignee must lose access, got %d", code)
+	}
+}
+
+func TestProjectRoles(t *testing.T) {
+	e := newTestEnv(t)
+	ana, bia, caio, duda := e.user("ana", "user"), e.user("bia", "user"), e.user("caio", "user"), e.user("duda", "user")
+
+	var project models.Project
+	if code := e.do("POST", "/api/projects", ana, fiber.Map{"name": "Site"}, &project); code != 201 {
+		t.Fatalf("create project: %d", code)
+	}
+	members := "/api/projects/" + itoa(project.ID) + "/members"
+	e.do("POST", members, ana, fiber.Map{"userId": bia.ID, "role": "editor"}, nil)
+	e.do("POST", members, ana, fiber.Map{"userId": caio.ID, "role": "viewer"}, nil)
+	if code := e.do("POST", members, bia, fiber.Map{"userId": duda.ID, "role": "viewer"}, nil); code != 403 {
+		t.Fatalf("only owners manage members, got %d", code)
+	}
+	if code := e.do("PATCH", members+"/"+itoa(ana.ID), ana, fiber.Map{"role": "editor"}, nil); code != 422 {
+		t.Fatalf("the last owner cannot be demoted, got %d", code)
+	}
+
+	if code := e.do("POST", "/api/tasks", caio, fiber.Map{"title": "x", "projectId": project.ID}, nil); code != 400 {
+		t.Fatalf("viewer must not create tasks in the project, got %d", code)
+	}
+	var task models.Task
+	if code := e.do("POST", "/api/tasks", bia, fiber.Map{"title": "Landing page", "projectId": project.ID}, &task); code != 201 {
+		t.Fatalf("editor create: %d", code)
+	}
+	path := "/api/tasks/" + itoa(task.ID)
+	if code := e.do("PATCH", path, ana, fiber.Map{"status": "doing"}, nil); code != 200 {
+		t.Fatalf("project owner update: %d", code)
+	}
+	if code := e.do("GET", path, caio, nil, nil); code != 200 {
+		t.Fatalf("viewer must see the task, got %d", code)
+	}
+	if code := e.do("POST", path+"/comments", caio, fiber.Map{"content": "ok"}, nil); code != 201 {
+		t.Fatalf("viewer must comment, got %d", code)
+	}
+	if code := e.do("PATCH", path, caio, fiber.Map{"title": "y"}, nil); code != 403 {
+		t.Fatalf("viewer must not edit, got %d", code)
+	}
+	if code := e.do("GET", path, duda, nil, nil); code != 404 {
+		t.Fatalf("outsider must not see the task, got %d", code)
+	}
+
+	var list struct {
+		Items []models.Task `json:"items"`
+	}
+	e.do("GET", "/api/tasks?projectId="+itoa(project.ID), caio, nil, &list)
+	if len(list.Items) != 1 {
+		t.Fatalf("project filter must list the task for its members, got %d", len(list.Items))
+	}
+	if code := e.do("DELETE", "/api/projects/"+itoa(project.ID), ana, nil, nil); code != 409 {
+		t.Fatalf("project with tasks must not be deleted, got %d", code)
+	}
+}
+
+func TestTenantIsolation(t *testing.T) {
+	e := newTestEnv(t)
+	acme, globex := models.Organization{Name: "Acme"}, models.Organization{Name: "Globex"}
+	e.db.Create(&acme)
+	e.db.Create(&globex)
+	ana, bia := e.userIn("ana", models.RoleUser, acme.ID), e.userIn("bia", models.RoleUser, acme.ID)
+	ada := e.userIn("ada", models.RoleOrgAdmin, acme.ID)
+	zed, zoe := e.userIn("zed", models.RoleUser, globex.ID), e.userIn("zoe", models.RoleOrgAdmin, globex.ID)
+
+	// assinantes em tempo real das duas organizações
+	subs := map[string]*ws.Client{}
+	for _, u := range []models.User{ada, zed, zoe} {
+		subs[u.Name] = e.hub.Subscribe(u.ID, u.Role, u.OrgID, 0)
+		defer e.hub.Unsubscribe(subs[u.Name])
+	}
+
+	if code := e.do("POST", "/api/tasks", ana, fiber.Map{"title": "x", "assigneeIds": []uint{zed.ID}}, nil); code != 400 {
+		t.Fatalf("users of another organization must not be assigned, got %d", code)
+	}
+	var task models.Task
+	if code := e.do("POST", "/api/tasks", ana, fiber.Map{"title": "Contrato Acme", "watcherIds": []uint{bia.ID}}, &task); code != 201 {
+		t.Fatalf("create: %d", code)
+	}
+	if task.OrgID != acme.ID {
+		t.Fatalf("task must belong to the creator's organization, got %d", task.OrgID)
+	}
+	e.do("POST", "/api/tasks/"+itoa(task.ID)+"/comments", ana, fiber.Map{"content": "@bia @zed confiram"}, nil)
+
+	path := "/api/tasks/" + itoa(task.ID)
+	for _, u := range []models.User{zed, zoe} {
+		if code := e.do("GET", path, u, nil, nil); code != 404 {
+			t.Fatalf("%s must not see the task, got %d", u.Name, code)
+		}
+		if code := e.do("PATCH", path, u, fiber.Map{"title": "hack"}, nil); code != 404 {
+			t.Fatalf("%s must not update the task, got %d", u.Name, code)
+		}
+		if code := e.do("GET", path+"/comments", u, nil, nil); code != 404 {
+			t.Fatalf("%s must not read comments, got %d", u.Name, code)
+		}
+		if code := e.do("POST", path+"/comments", u, fiber.Map{"content": "oi"}, nil); code != 404 {
+			t.Fatalf("%s must not comment, got %d", u.Name, code)
+		}
+	}
+	var own models.Task
+	e.do("POST", "/api/tasks", zed, fiber.Map{"title": "Globex"}, &own)
+	if code := e.do("POST", "/api/tasks/"+itoa(own.ID)+"/dependencies", zed, fiber.Map{"blockerId": task.ID}, nil); code != 400 {
+		t.Fatalf("tasks of another organization must not block, got %d", code)
+	}
+
+	count := func(as models.User) int {
+		var out struct {
+			Items []models.Task `json:"items"`
+		}
+		e.do("GET", "/api/tasks", as, nil, &out)
+		return len(out.Items)
+	}
+	if count(zoe) != 1 || count(zed) != 1 || count(ada) != 1 || count(bia) != 1 {
+		t.Fatal("org_admin must list every task of the organization and only those")
+	}
+	var users []models.User
+	e.do("GET", "/api/org/users", zoe, nil, &users)
+	if len(users) != 2 {
+		t.Fatalf("org users must be scoped, got %d", len(users))
+	}
+	// notificações: a menção a alguém de fora é ignorada
+	var mentions []uint
+	e.db.Model(&models.Notification{}).Where("type = ?", models.NotificationMention).Pluck("user_id", &mentions)
+	if len(mentions) != 1 || mentions[0] != bia.ID {
+		t.Fatalf("only bia must be mentioned, got %v", mentions)
+	}
+
+	// eventos: nada da Acme chega à Globex, e vice-versa
+	time.Sleep(100 * time.Millisecond)
+	drain := func(name string) []ws.Event {
+		var out []ws.Event
+		for {
+			select {
+			case ev := <-subs[name].Events():
+				out = append(out, ev)
+			default:
+				return out
+			}
+		}
+	}
+	sawTask := false
+	for _, ev := range drain("ada") {
+		if ev.Type == "task.created" && ev.Org == globex.ID {
+			t.Fatalf("acme admin received a globex event: %+v", ev)
+		}
+		sawTask = sawTask || ev.Type == "task.created"
+	}
+	if !sawTask {
+		t.Fatal("org_admin must receive the organization's task events")
+	}
+	for _, name := range []string{"zed", "zoe"} {
+		for _, ev := range drain(name) {
+			if ev.Org != globex.ID {
+				t.Fatalf("%s received an event from another organization: %s %+v", name, ev.Type, ev.Payload)
+			}
+		}
+	}
+
+	// trocar o papel revoga as sessões na hora; a última org_admin não sai
+	if code := e.do("PATCH", "/api/org/users/"+itoa(zed.ID), zoe, fiber.Map{"role": models.RoleOrgAdmin}, nil); code != 200 {
+		t.Fatalf("promote: %d", code)
+	}
+	if code := e.do("PATCH", "/api/org/users/"+itoa(zed.ID), zoe, fiber.Map{"role": models.RoleUser}, nil); code != 200 {
+		t.Fatalf("demote: %d", code)
+	}
+	var demoted models.User
+	e.db.First(&demoted, zed.ID)
+	if demoted.Role != models.RoleUser || demoted.TokenVersion != zed.TokenVersion+2 {
+		t.Fatalf("role changes must revoke the user's tokens, got %+v", demoted)
+	}
+	if code := e.do("PATCH", "/api/org/users/"+itoa(zoe.ID), zoe, fiber.Map{"role": models.RoleUser}, nil); code != 422 {
+		t.Fatalf("last org_admin must stay, got %d", code)
+	}
+
+}
+
+func TestProjectWorkflowTransitions(t *testing.T) {
+	e := newTestEnv(t)
+	ana := e.user("ana", "user")
+	var project models.Project
+	e.do("POST", "/api/projects", ana, fiber.Map{"name": "Releases"}, &project)
+	workflow := "/api/projects/" + itoa(project.ID) + "/workflow"
+	statuses := []fiber.Map{
+		{"key": "todo", "name": "A fazer", "category": "not_started"},
+		{"key": "doing", "name": "Fazendo", "category": "in_progress"},
+		{"key": "review", "name": "Revisão", "category": "in_progress"},
+		{"key": "shipped", "name": "Publicado", "category": "done"},
+	}
+	transitions := fiber.Map{"todo": []string{"doing"}, "doing": []string{"review", "todo"}, "review": []string{"shipped", "doing"}}
+	if code := e.do("PUT", workflow, ana, fiber.Map{"statuses": statuses[:3], "transitions": transitions}, nil); code != 400 {
+		t.Fatalf("a workflow without a done status must be rejected, got %d", code)
+	}
+	if code := e.do("PUT", workflow, ana, fiber.Map{"statuses": statuses, "transitions": transitions}, nil); code != 200 {
+		t.Fatalf("put workflow: %d", code)
+	}
+
+	var task models.Task
+	if code := e.do("POST", "/api/tasks", ana, fiber.Map{"title": "v2.0", "projectId": project.ID}, &task); code != 201 {
+		t.Fatalf("create: %d", code)
+	}
+	if task.Status != "todo" || task.StatusCategory != models.CategoryNotStarted {
+		t.Fatalf("task must start in the first status, got %s/%s", task.Status, task.StatusCategory)
+	}
+	path := "/api/tasks/" + itoa(task.ID)
+	var rejected struct {
+		Allowed []string `json:"allowed"`
+	}
+	if code := e.do("PATCH", path, ana, fiber.Map{"status": "shipped"}, &rejected); code != 422 {
+		t.Fatalf("todo -> shipped must be rejected, got %d", code)
+	}
+	if len(rejected.Allowed) != 1 || rejected.Allowed[0] != "doing" {
+		t.Fatalf("422 must list the valid next statuses, got %v", rejected.Allowed)
+	}
+	for _, st := range []string{"doing", "review", "shipped"} {
+		if code := e.do("PATCH", path, ana, fiber.Map{"status": st}, &task); code != 200 {
+			t.Fatalf("move to %s: %d", st, code)
+		}
+	}
+	if task.StatusCategory != models.CategoryDone {
+		t.Fatalf("shipped must be in the done category, got %s", task.StatusCategory)
+	}
+	if code := e.do("PUT", workflow, ana, fiber.Map{"statuses": append(statuses[:3:3], fiber.Map{"key": "done", "name": "Feito", "category": "done"})}, nil); code != 409 {
+		t.Fatalf("removing a status in use must conflict, got %d", code)
+	}
+}
+
+func TestBoardMoveKeepsManualOrder(t *testing.T) {
+	e := newTestEnv(t)
+	ana := e.user("ana", "user")
+	ids := map[string]uint{}
+	for _, title := range []string{"a", "b", "c", "d"} {
+		var task models.Task
+		if code := e.do("POST", "/api/tasks", ana, fiber.Map{"title": title}, &task); code != 201 {
+			t.Fatalf("create: %d", code)
+		}
+		ids[title] = task.ID
+	}
+	column := func(status string) []string {
+		t.Helper()
+		var board struct {
+			Columns []struct {
+				Status string        `json:"status"`
+				Tasks  []models.Task `json:"tasks"`
+			} `json:"columns"`
+		}
+		if code := e.do("GET", "/api/board", ana, nil, &board); code != 200 {
+			t.Fatalf("board: %d", code)
+		}
+		for _, col := range board.Columns {
+			if col.Status == status {
+				titles := []string{}
+				for _, task := range col.Tasks {
+					titles = append(titles, task.Title)
+				}
+				return titles
+			}
+		}
+		t.Fatalf("no column %s", status)
+		return nil
+	}
+	move := func(title string, body fiber.Map) int {
+		return e.do("POST", "/api/tasks/"+itoa(ids[title])+"/move", ana, body, nil)
+	}
+	if got := fmt.Sprint(column("todo")); got != "[a b c d]" {
+		t.Fatalf("new tasks go to the end of the column, got %s", got)
+	}
+
+	var before models.Task
+	e.db.First(&before, ids["b"])
+	if code := move("d", fiber.Map{"afterId": ids["a"]}); code != 200 {
+		t.Fatalf("move: %d", code)
+	}
+	if got := fmt.Sprint(column("todo")); got != "[a d b c]" {
+		t.Fatalf("afterId must place the task right below it, got %s", got)
+	}
+	var after models.Task
+	e.db.First(&after, ids["b"])
+	if after.Position != before.Position {
+		t.Fatal("a move must rewrite only the moved task")
+	}
+	sub := e.hub.Subscribe(ana.ID, ana.Role, ana.OrgID, 0)
+	defer e.hub.Unsubscribe(sub)
+	if code := move("c", fiber.Map{"status": "doing"}); code != 200 {
+		t.Fatalf("move to doing: %d", code)
+	}
+	// quem assina task.updated (ex.: webhooks) também fica sabendo da mudança pelo quadro
+	for got, deadline := false, time.After(time.Second); !got; {
+		select {
+		case ev := <-sub.Events():
+			moved, ok := ev.Payload.(models.Task)
+			// o hub entrega em outra goroutine: eventos do movimento anterior ainda podem chegar
+			if ev.Type != "task.updated" || !ok || moved.ID != ids["c"] {
+				continue
+			}
+			if moved.Status != models.StatusDoing || moved.Version != 2 {
+				t.Fatalf("task.updated must carry the moved task, got %+v", moved)
+			}
+			got = true
+		case <-deadline:
+			t.Fatal("a board move must broadcast task.updated")
+		}
+	}
+	if code := move("a", fiber.Map{"status": "doing", "beforeId": ids["c"]}); code != 200 {
+		t.Fatalf("move before c: %d", code)
+	}
+	if got := fmt.Sprint(column("doing")); got != "[a c]" {
+		t.Fatalf("beforeId must place the task right above it, got %s", got)
+	}
+	if code := move("b", fiber.Map{"afterId": ids["c"]}); code != 400 {
+		t.Fatalf("a neighbor from another column must be rejected, got %d", code)
+	}
+
+	// sem espaço entre os vizinhos, a coluna é renumerada e a ordem pedida vale
+	var last models.Task
+	if code := e.do("POST", "/api/tasks", ana, fiber.Map{"title": "e"}, &last); code != 201 {
+		t.Fatalf("create: %d", code)
+	}
+	e.db.Model(&models.Task{}).Where("id = ?", ids["d"]).Update("position", 4.999999999999999)
+	e.db.Model(&models.Task{}).Where("id = ?", last.ID).Update("position", 5)
+	if code := move("b", fiber.Map{"afterId": ids["d"]}); code != 200 {
+		t.Fatalf("move into a full gap: %d", code)
+	}
+	if got := fmt.Sprint(column("todo")); got != "[d b e]" {
+		t.Fatalf("order after renumbering, got %s", got)
+	}
+}
+
+func TestTaskHistoryAndRestore(t *testing.T) {
+	e := newTestEnv(t)
+	ana := e.user("ana", "user")
+	bob := e.user("bob", "user")
+	var task models.Task
+	if code := e.do("POST", "/api/tasks", ana, fiber.Map{"title": "Rascunho", "priority": "low"}, &task); code != 201 {
+		t.Fatalf("create: %d", code)
+	}
+	path := "/api/tasks/" + itoa(task.ID)
+	due := time.Date(2026, 5, 4, 12, 0, 0, 0, time.UTC)
+	if code := e.do("PATCH", path, ana, fiber.Map{"title": "Final", "priority": "high", "dueDate": due}, nil); code != 200 {
+		t.Fatalf("patch: %d", code)
+	}
+	// o cliente não escolhe a origem da revisão
+	if code := e.do("PATCH", path+"?source=ai", ana, fiber.Map{"description": "sugerida"}, nil); code != 200 {
+		t.Fatalf("patch ai: %d", code)
+	}
+	if code := e.do("PATCH", path, ana, fiber.Map{"title": "Final"}, nil); code != 200 {
+		t.Fatalf("patch noop: %d", code)
+	}
+
+	var hist struct {
+		Items []models.TaskRevision `json:"items"`
+	}
+	if code := e.do("GET", path+"/history", ana, nil, &hist); code != 200 {
+		t.Fatalf("history: %d", code)
+	}
+	if len(hist.Items) != 3 {
+		t.Fatalf("want creation and two edits (a no-op edit is not recorded), got %d", len(hist.Items))
+	}
+	ai, edit, created := hist.Items[0], hist.Items[1], hist.Items[2]
+	if ai.Source != models.SourceAPI || len(ai.Changes) != 1 || ai.Changes[0].Field != "description" {
+		t.Fatalf("a client-supplied source must be ignored: %+v", ai)
+	}
+	if edit.Source != models.SourceAPI || edit.ActorID == nil || *edit.ActorID != ana.ID || edit.Actor == nil {
+		t.Fatalf("the revision must record the actor: %+v", edit)
+	}
+	var fields []string
+	for _, ch := range edit.Changes {
+		fields = append(fields, ch.Field)
+	}
+	if fmt.Sprint(fields) != "[title priority dueDate]" || string(edit.Changes[0].From) != `"Rascunho"` || string(edit.Changes[1].To) != `"high"` {
+		t.Fatalf("field-level diff: %+v", edit.Changes)
+	}
+	if code := e.do("GET", path+"/history", bob, nil, nil); code != 404 {
+		t.Fatalf("history of someone else's task must be hidden, got %d", code)
+	}
+
+	if code := e.do("POST", path+"/history/"+itoa(created.ID)+"/restore", ana, nil, &task); code != 200 {
+		t.Fatalf("restore: %d", code)
+	}
+	if task.Title != "Rascunho" || task.Priority != models.PriorityLow || task.DueDate != nil || task.Description != "" {
+		t.Fatalf("restore must bring back the revision state, got %+v", task)
+	}
+	e.do("GET", path+"/history", ana, nil, &hist)
+	if len(hist.Items) != 4 || hist.Items[0].RestoredFrom == nil || *hist.Items[0].RestoredFrom != created.ID {
+		t.Fatalf("restore must be recorded as a new revision, got %+v", hist.Items[0])
+	}
+}
+
+func TestDeleteComment(t *testing.T) {
+	e := newTestEnv(t)
+	ana, bia, caio := e.user("ana", "user"), e.user("bia", "user"), e.user("caio", "user")
+	var task models.Task
+	e.do("POST", "/api/tasks", ana, fiber.Map{"title": "Revisão", "assigneeIds": []uint{bia.ID}}, &task)
+	comments := "/api/tasks/" + itoa(task.ID) + "/comments"
+	var mine, hers models.Comment
+	e.do("POST", comments, bia, fiber.Map{"content": "feito"}, &mine)
+	e.do("POST", comments, ana, fiber.Map{"content": "obrigada"}, &hers)
+
+	if code := e.do("DELETE", comments+"/"+itoa(mine.ID), caio, nil, nil); code != 404 {
+		t.Fatalf("outsider must not see the comment, got %d", code)
+	}
+	if code := e.do("DELETE", comments+"/"+itoa(hers.ID), bia, nil, nil); code != 403 {
+		t.Fatalf("only the author or the task owner deletes, got %d", code)
+	}
+	if code := e.do("DELETE", comments+"/"+itoa(mine.ID), bia, nil, nil); code != 204 {
+		t.Fatalf("author delete: %d", code)
+	}
+	var list []models.Comment
+	e.do("GET", comments, ana, nil, &list)
+	if len(list) != 1 || list[0].ID != hers.ID {
+		t.Fatalf("deleted comment must leave the list, got %+v", list)
+	}
+	var trashed models.Comment
+	if err := e.db.Unscoped().First(&trashed, mine.ID).Error; err != nil || !trashed.DeletedAt.Valid {
+		t.Fatalf("comment must stay in the trash until purged: %v %+v", err, trashed)
+	}
+	if code := e.do("DELETE", comments+"/"+itoa(hers.ID), ana, nil, nil); code != 204 {
+		t.Fatalf("owner delete: %d", code)
+	}
+}
+
+func TestTrashAndRestore(t *testing.T) {
+	e := newTestEnv(t)
+	ana := e.user("ana", "user")
+	bob := e.user("bob", "user")
+	var parent, sub models.Task
+	e.do("POST", "/api/tasks", ana, fiber.Map{"title": "Mãe"}, &parent)
+	e.do("POST", "/api/tasks", ana, fiber.Map{"title": "Filha", "parentId": parent.ID}, &sub)
+	path := "/api/tasks/" + itoa(parent.ID)
+	e.do("POST", path+"/comments", ana, fiber.Map{"content": "nota"}, nil)
+
+	if code := e.do("DELETE", path, ana, nil, nil); code != 204 {
+		t.Fatalf("delete: %d", code)
+	}
+	if code := e.do("GET", "/api/tasks/"+itoa(sub.ID), ana, nil, nil); code != 404 {
+		t.Fatalf("a task in the trash must be hidden, got %d", code)
+	}
+	var trash struct {
+		Items []struct {
+			ID      uint      `json:"id"`
+			PurgeAt time.Time `json:"purgeAt"`
+		} `json:"items"`
+		RetentionDays int `json:"retentionDays"`
+	}
+	if code := e.do("GET", "/api/trash", ana, nil, &trash); code != 200 {
+		t.Fatalf("trash: %d", code)
+	}
+	if len(trash.Items) != 1 || trash.Items[0].ID != parent.ID || trash.RetentionDays != 30 {
+		t.Fatalf("the trash lists only what was deleted directly, got %+v", trash)
+	}
+	if until := time.Until(trash.Items[0].PurgeAt); until < 29*24*time.Hour || until > 30*24*time.Hour {
+		t.Fatalf("purgeAt must be the deletion plus the retention, got %v", trash.Items[0].PurgeAt)
+	}
+	e.do("GET", "/api/trash", bob, nil, &trash)
+	if len(trash.Items) != 0 {
+		t.Fatal("someone else's trash must not be listed")
+	}
+	if code := e.do("POST", path+"/restore", bob, nil, nil); code != 404 {
+		t.Fatalf("an outsider cannot restore, got %d", code)
+	}
+	if code := e.do("POST", "/api/tasks/"+itoa(sub.ID)+"/restore", ana, nil, nil); code != 409 {
+		t.Fatalf("a subtask waits for its parent, got %d", code)
+	}
+
+	if code := e.do("POST", path+"/restore", ana, nil, nil); code != 200 {
+		t.Fatalf("restore: %d", code)
+	}
+	if code := e.do("GET", "/api/tasks/"+itoa(sub.ID), ana, nil, nil); code != 200 {
+		t.Fatalf("the subtask comes back with its parent, got %d", code)
+	}
+	var comments []models.Comment
+	if e.do("GET", path+"/comments", ana, nil, &comments); len(comments) != 1 {
+		t.Fatalf("comments come back with the task, got %d", len(comments))
+	}
+	if code := e.do("POST", path+"/restore", ana, nil, nil); code != 409 {
+		t.Fatalf("restoring a live task must conflict, got %d", code)
+	}
+}
+
+func itoa(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+func TestTaskOptimisticConcurrency(t *testing.T) {
+	e := newTestEnv(t)
+	ana := e.user("ana", "user")
+
+	var task models.Task
+	if code := e.do("POST", "/api/tasks", ana, fiber.Map{"title": "Relatório"}, &task); code != 201 || task.Version != 1 {
+		t.Fatalf("create: %d version %d", code, task.Version)
+	}
+	path := "/api/tasks/" + itoa(task.ID)
+	code, header := e.doWith("GET", path, ana, nil, nil, nil)
+	etag := header.Get("ETag")
+	if code != 200 || etag != `"1"` {
+		t.Fatalf("get: %d etag %q", code, etag)
+	}
+
+	// outra aba grava primeiro
+	var updated models.Task
+	if code, header := e.doWith("PATCH", path, ana, map[string]string{"If-Match": etag}, fiber.Map{"title": "Relatório final"}, &updated); code != 200 ||
+		updated.Version != 2 || header.Get("ETag") != `"2"` {
+		t.Fatalf("patch: %d version %d etag %q", code, updated.Version, header.Get("ETag"))
+	}
+
+	// a edição com o ETag antigo não sobrescreve e recebe o estado atual
+	var current models.Task
+	if code, header := e.doWith("PATCH", path, ana, map[string]string{"If-Match": etag}, fiber.Map{"title": "Rascunho"}, &current); code != 412 ||
+		current.Title != "Relatório final" || current.Version != 2 || header.Get("ETag") != `"2"` {
+		t.Fatalf("stale patch: %d %q version %d", code, current.Title, current.Version)
+	}
+
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"goTasks/internal/models"
+	"goTasks/internal/policy"
+	"goTasks/internal/trash"
+	"goTasks/internal/ws"
+)
+
+// trashItem é uma tarefa da lixeira com a data em que será apagada de vez.
+type trashItem struct {
+	models.Task
+	PurgeAt time.Time `json:"purgeAt"`
+}
+
+// Trash lista o que o usuário pode restaurar: as tarefas excluídas diretamente
+// (subtarefas que foram junto voltam com a mãe), das mais recentes para as mais antigas.
+func (h *TaskHandler) Trash(c *fiber.Ctx) error {
+	days, err := trash.Retention(h.db)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	page := parseIntDefault(c.Query("page"), 1)
+	size := parseIntDefault(c.Query("size"), 20)
+	if size > 100 {
+		size = 100
+	}
+	if page < 1 {
+		page = 1
+	}
+	qry := h.db.Unscoped().Preload("Owner").Where("deleted_at IS NOT NULL").
+		Where("NOT EXISTS (SELECT 1 FROM tasks p WHERE p.id = tasks.parent_id AND p.deleted_at = tasks.deleted_at)")
+	if a := actor(c); !a.Admin {
+		qry = qry.Where("id IN (?)", policy.OwnedTaskIDs(h.db, a))
+	}
+	var tasks []models.Task
+	if err := qry.Order("deleted_at DESC, id DESC").Limit(size).Offset((page - 1) * size).Find(&tasks).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	items := make([]trashItem, len(tasks))
+	for i, t := range tasks {
+		items[i] = trashItem{Task: t, PurgeAt: t.DeletedAt.Time.AddDate(0, 0, days)}
+	}
+	return c.JSON(fiber.Map{"items": items, "page": page, "size": size, "count": len(items), "retentionDays": days})
+}
+
+// RestoreFromTrash tira a tarefa da lixeira junto com as subtarefas e os comentários excluídos com ela.
+func (h *TaskHandler) RestoreFromTrash(c *fiber.Ctx) error {
+	var task models.Task
+	if err := h.db.Unscoped().First(&task, "id = ?", c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
+	}
+	uid, ok := c.Locals("userID").(uint)
+	if !ok || uid == 0 {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	role, err := policy.OnTask(h.db, actor(c), task)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	if role == policy.None {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "task not found"})
+	}
+	if role < policy.Owner {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "not allowed"})
+	}
+	if !task.DeletedAt.Valid {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "task is not in the trash"})
+	}
+	// mãe ainda na lixeira: restaurar a mãe primeiro; mãe já apagada de vez: a tarefa vira raiz
+	detach := false
+	if task.ParentID != nil {
+		var parent models.Task
+		if err := h.db.Unscoped().Select("id", "deleted_at").Limit(1).Find(&parent, "id = ?", *task.ParentID).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+		}
+		if parent.DeletedAt.Valid {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "restore the parent task first", "parentId": parent.ID})
+		}
+		detach = parent.ID == 0
+	}
+	wf, err := workflowFor(h.db, task.ProjectID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	at := task.DeletedAt.Time
+	ids, err := trashedTree(h.db, task.ID, at)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		// voltar da lixeira conta como mudança: quem guardou o ETag de antes precisa reler
+		if err := tx.Unscoped().Model(&models.Task{}).Where("id IN ?", ids).
+			UpdateColumns(map[string]interface{}{"deleted_at": nil, "version": gorm.Expr("version + 1")}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Comment{}).Where("task_id IN ? AND deleted_at = ?", ids, at).UpdateColumn("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		if detach {
+			if err := tx.Model(&models.Task{}).Where("id = ?", task.ID).UpdateColumn("parent_id", nil).Error; err != nil {
+				return err
+			}
+		}
+		// status que saíram do workflow enquanto a tarefa estava na lixeira voltam para o inicial
+		return tx.Model(&models.Task{}).Where("id IN ? AND status NOT IN ?", ids, wf.Keys()).
+			UpdateColumns(map[string]interface{}{"status": wf.Initial(), "status_category": wf.Category(wf.Initial())}).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	if err := h.db.Preload("Owner").Preload("Labels").Preload("Assignees").Preload("Watchers").First(&task, "id = ?", task.ID).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	h.hub.Broadcast(ws.Event{Type: "task.restored", Payload: fiber.Map{"task": task, "subtasks": ids[1:]}, To: policy.Audience(h.db, task), Org: task.OrgID})
+	if task.ParentID != nil {
+		broadcastProgress(h.db, h.hub, *task.ParentID)
+	}
+	return c.JSON(task)
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"goTasks/internal/models"
+	"goTasks/internal/outbound"
+	"goTasks/internal/webhook"
+)
+
+type WebhookHandler struct {
+	db         *gorm.DB
+	dispatcher *webhook.Dispatcher
+}
+
+func NewWebhookHandler(db *gorm.DB, dispatcher *webhook.Dispatcher) *WebhookHandler {
+	return &WebhookHandler{db: db, dispatcher: dispatcher}
+}
+
+// hook carrega o webhook se pertencer ao usuário (ou se for admin)
+func (h *WebhookHandler) hook(c *fiber.Ctx) (models.Webhook, bool) {
+	var hook models.Webhook
+	if err := h.db.First(&hook, "id = ?", c.Params("id")).Error; err != nil {
+		return hook, false
+	}
+	uid, _ := c.Locals("userID").(uint)
+	userRole, _ := c.Locals("userRole").(string)
+	return hook, userRole == "admin" || hook.UserID == uid
+}
+
+// List devolve os webhooks do usuário
+func (h *WebhookHandler) List(c *fiber.Ctx) error {
+	uid, _ := c.Locals("userID").(uint)
+	hooks := []models.Webhook{}
+	if err := h.db.Where("user_id = ?", uid).Order("id").Find(&hooks).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.JSON(hooks)
+}
+
+// Create registra um webhook; o segredo de assinatura só é devolvido aqui
+func (h *WebhookHandler) Create(c *fiber.Ctx) error {
+	uid, ok := c.Locals("userID").(uint)
+	if !ok || uid == 0 {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	var body struct {
+		URL         string   `json:"url"`
+		Description string   `json:"description"`
+		Events      []string `json:"events"`
+	}
+	if err := c.BodyParser(&body); err != nil || !validWebhookURL(body.URL) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid url"})
+	}
+	if err := validateEvents(body.Events); err != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err})
+	}
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	hook := models.Webhook{
+		UserID:      uid,
+		URL:         body.URL,
+		Description: body.Description,
+		Secret:      secret,
+		Events:      body.Events,
+		Active:      true,
+	}
+	if err := h.db.Create(&hook).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"webhook": hook, "secret": secret})
+}
+
+// Update altera url/eventos ou reativa um webhook desativado por falhas
+func (h *WebhookHandler) Update(c *fiber.Ctx) error {
+	hook, ok := h.hook(c)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "webhook not found"})
+	}
+	var body struct {
+		URL         *string   `json:"url"`
+		Description *string   `json:"description"`
+		Events      *[]string `json:"events"`
+		Active      *bool     `json:"active"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid data"})
+	}
+	updates := map[string]interface{}{}
+	if body.URL != nil {
+		if !validWebhookURL(*body.URL) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid url"})
+		}
+		updates["url"] = *body.URL
+	}
+	if body.Description != nil {
+		updates["description"] = *body.Description
+	}
+	if body.Events != nil {
+		if err := validateEvents(*body.Events); err != "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err})
+		}
+		hook.Events = *body.Events
+		updates["events"] = hook.Events
+	}
+	if body.Active != nil {
+		updates["active"] = *body.Active
+		if *body.Active {
+			updates["failure_count"] = 0
+			updates["disabled_at"] = nil
+		}
+	}
+	if len(updates) > 0 {
+		if err := h.db.Model(&hook).Updates(updates).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+		}
+	}
+	if err := h.db.First(&hook, hook.ID).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.JSON(hook)
+}
+
+// Delete remove o webhook e seu histórico de entregas
+func (h *WebhookHandler) Delete(c *fiber.Ctx) error {
+	hook, ok := h.hook(c)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "webhook not found"})
+	}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", hook.ID).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&hook).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Deliveries lista o log de entregas, mais recentes primeiro (cursor = último id)
+func (h *WebhookHandler) Deliveries(c *fiber.Ctx) error {
+	hook, ok := h.hook(c)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "webhook not found"})
+	}
+	limit := parseIntDefault(c.Query("limit"), 20)
+	if limit < 1 || limit > 100 {
+		limit = 100
+	}
+	qry := h.db.Where("webhook_id = ?", hook.ID)
+	if cursor := parseIntDefault(c.Query("cursor"), 0); cursor > 0 {
+		qry = qry.Where("id < ?", cursor)
+	}
+	items := []models.WebhookDelivery{}
+	if err := qry.Order("id DESC").Limit(limit).Find(&items).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	var next *uint
+	if len(items) == limit {
+		next = &items[len(items)-1].ID
+	}
+	return c.JSON(fiber.Map{"items": items, "nextCursor": next})
+}
+
+// Redeliver reenvia uma entrega anterior com o mesmo corpo (nova assinatura e timestamp)
+func (h *WebhookHandler) Redeliver(c *fiber.Ctx) error {
+	hook, ok := h.hook(c)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "webhook not found"})
+	}
+	if !hook.Active {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "webhook is disabled"})
+	}
+	var prev models.WebhookDelivery
+	if err := h.db.First(&prev, "id = ? AND webhook_id = ?", c.Params("deliveryId"), hook.ID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "delivery not found"})
+	}
+	next, err := h.dispatcher.Redeliver(prev)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.Status(fiber.StatusAccepted).JSON(next)
+}
+
+// validWebhookURL aceita só http(s) cujo host resolve para endereços públicos (nada de
+// loopback, rede privada ou metadados da nuvem); o cliente de entrega checa de novo no connect.
+func validWebhookURL(raw string) bool {
+	return outbound.CheckURL(raw) == nil
+}
+
+func validateEvents(events []string) string {
+	for _, e := range events {
+		if !webhook.Supported(e) {
+			return "unknown event: " + e
+		}
+	}
+	return ""
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"goTasks/internal/models"
+	"goTasks/internal/policy"
+	"goTasks/internal/ws"
+)
+
+// workflowFor devolve o workflow do projeto (o padrão para tarefas sem projeto ou projetos sem workflow próprio).
+func workflowFor(db *gorm.DB, projectID *uint) (models.Workflow, error) {
+	if projectID == nil {
+		return models.DefaultWorkflow(), nil
+	}
+	var wf models.Workflow
+	if err := db.Limit(1).Find(&wf, "project_id = ?", *projectID).Error; err != nil {
+		return wf, err
+	}
+	if wf.ID == 0 {
+		wf = models.DefaultWorkflow()
+		wf.ProjectID = *projectID
+	}
+	return wf, nil
+}
+
+// statusError monta a resposta 422 de um status fora do workflow ou de uma transição proibida.
+func statusError(c *fiber.Ctx, wf models.Workflow, from, to models.TaskStatus, moved bool) error {
+	if _, ok := wf.Status(to); !ok || moved || from == "" {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":   fmt.Sprintf("status %q is not part of the workflow", to),
+			"allowed": wf.Keys(),
+		})
+	}
+	return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+		"error":   fmt.Sprintf("cannot move from %q to %q", from, to),
+		"allowed": wf.Next(from),
+	})
+}
+
+type WorkflowHandler struct {
+	db       *gorm.DB
+	hub      *ws.Hub
+	projects *ProjectHandler
+}
+
+func NewWorkflowHandler(db *gorm.DB, hub *ws.Hub) *WorkflowHandler {
+	return &WorkflowHandler{db: db, hub: hub, projects: NewProjectHandler(db, hub)}
+}
+
+// Get devolve o workflow do projeto
+func (h *WorkflowHandler) Get(c *fiber.Ctx) error {
+	p, ok, err := h.projects.project(c, policy.Viewer)
+	if !ok {
+		return err
+	}
+	wf, err := workflowFor(h.db, &p.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	return c.JSON(wf)
+}
+
+// Put substitui o workflow do projeto. Status em uso por tarefas não podem sair;
+// as tarefas acompanham a nova categoria dos seus status.
+func (h *WorkflowHandler) Put(c *fiber.Ctx) error {
+	p, ok, err := h.projects.project(c, policy.Owner)
+	if !ok {
+		return err
+	}
+	var body struct {
+		Statuses    []models.WorkflowStatus                   `json:"statuses"`
+		Transitions map[models.TaskStatus][]models.TaskStatus `json:"transitions"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid data"})
+	}
+	wf, err := workflowFor(h.db, &p.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	wf.Statuses, wf.Transitions = body.Statuses, body.Transitions
+	if err := wf.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	var inUse []models.TaskStatus
+	if err := h.db.Model(&models.Task{}).Where("project_id = ? AND status NOT IN ?", p.ID, wf.Keys()).
+		Distinct().Pluck("status", &inUse).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	if len(inUse) > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "statuses still used by tasks", "statuses": inUse})
+	}
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&wf).Error; err != nil {
+			return err
+		}
+		// inclui as tarefas na lixeira: ao voltar, precisam estar com a categoria atual
+		for _, s := range wf.Statuses {
+			if err := tx.Unscoped().Model(&models.Task{}).Where("project_id = ? AND status = ?", p.ID, s.Key).
+				Update("status_category", s.Category).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+	}
+	h.hub.Broadcast(ws.Event{Type: "workflow.updated", Payload: wf, To: h.projects.members(p.ID), Org: p.OrgID})
+	return c.JSON(wf)
+}
@@ -0,0 +1,105 @@
+// Package history guarda o histórico das tarefas: cada revisão diz quem mudou quais campos, quando e por onde.
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"gorm.io/gorm"
+
+	"goTasks/internal/models"
+)
+
+// Meta identifica a origem de uma mudança.
+type Meta struct {
+	ActorID      *uint
+	Source       string
+	RestoredFrom *uint
+}
+
+// By é uma mudança feita pelo usuário via API.
+func By(userID uint) Meta {
+	return Meta{ActorID: &userID, Source: models.SourceAPI}
+}
+
+// fields na ordem em que as mudanças aparecem
+var fields = []string{"title", "description", "status", "priority", "dueDate", "ownerId", "projectId", "parentId", "labelIds", "assigneeIds", "watcherIds"}
+
+// Take lê o estado atual da tarefa.
+func Take(db *gorm.DB, taskID uint) (models.TaskSnapshot, uint, error) {
+	var task models.Task
+	if err := db.First(&task, "id = ?", taskID).Error; err != nil {
+		return models.TaskSnapshot{}, 0, err
+	}
+	s := models.TaskSnapshot{
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      task.Status,
+		Priority:    task.Priority,
+		DueDate:     task.DueDate,
+		OwnerID:     task.OwnerID,
+		ProjectID:   task.ProjectID,
+		ParentID:    task.ParentID,
+	}
+	for table, out := range map[string]*[]uint{"task_labels": &s.LabelIDs, models.TaskAssigneesTable: &s.AssigneeIDs, models.TaskWatchersTable: &s.WatcherIDs} {
+		col := "user_id"
+		if table == "task_labels" {
+			col = "label_id"
+		}
+		if err := db.Table(table).Where("task_id = ?", taskID).Order(col).Pluck(col, out).Error; err != nil {
+			return models.TaskSnapshot{}, 0, err
+		}
+	}
+	return s, task.OrgID, nil
+}
+
+// Diff lista os campos que mudaram de before para after.
+func Diff(before, after models.TaskSnapshot) []models.FieldChange {
+	b, a := encode(before), encode(after)
+	var changes []models.FieldChange
+	for _, f := range fields {
+		if !bytes.Equal(b[f], a[f]) {
+			changes = append(changes, models.FieldChange{Field: f, From: b[f], To: a[f]})
+		}
+	}
+	return changes
+}
+
+func encode(s models.TaskSnapshot) map[string]json.RawMessage {
+	// lista vazia e nil são o mesmo estado
+	for _, ids := range []*[]uint{&s.LabelIDs, &s.AssigneeIDs, &s.WatcherIDs} {
+		if *ids == nil {
+			*ids = []uint{}
+		}
+	}
+	raw, _ := json.Marshal(s)
+	out := map[string]json.RawMessage{}
+	json.Unmarshal(raw, &out)
+	return out
+}
+
+// Record grava uma revisão com o estado atual da tarefa comparado a before
+// (nil = tarefa recém-criada). Sem mudanças, não grava nada.
+func Record(tx *gorm.DB, taskID uint, before *models.TaskSnapshot, meta Meta) error {
+	after, orgID, err := Take(tx, taskID)
+	if err != nil {
+		return err
+	}
+	var prev models.TaskSnapshot
+	if before != nil {
+		prev = *before
+	}
+	changes := Diff(prev, after)
+	if len(changes) == 0 {
+		return nil
+	}
+	return tx.Create(&models.TaskRevision{
+		TaskID:       taskID,
+		OrgID:        orgID,
+		ActorID:      meta.ActorID,
+		Source:       meta.Source,
+		Changes:      changes,
+		State:        after,
+		RestoredFrom: meta.RestoredFrom,
+	}).Error
+}
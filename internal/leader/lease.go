@@ -0,0 +1,85 @@
+package leader
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"goTasks/internal/models"
+)
+
+// Lease implementa eleição de líder por arrendamento numa tabela: quem detém a linha
+// não expirada é o líder; renova a cada rodada e, se cair, outra réplica assume após o TTL.
+type Lease struct {
+	db     *gorm.DB
+	name   string
+	holder string
+	ttl    time.Duration
+}
+
+func NewLease(db *gorm.DB, name string, ttl time.Duration) *Lease {
+	return &Lease{db: db, name: name, holder: holderID(), ttl: ttl}
+}
+
+// Holder identifica esta réplica.
+func (l *Lease) Holder() string {
+	return l.holder
+}
+
+// TryAcquire renova o arrendamento se já for nosso ou o toma se estiver vencido.
+func (l *Lease) TryAcquire() (bool, error) {
+	now := time.Now()
+	res := l.db.Model(&models.Lease{}).
+		Where("name = ? AND (holder = ? OR expires_at < ?)", l.name, l.holder, now).
+		Updates(map[string]interface{}{"holder": l.holder, "expires_at": now.Add(l.ttl)})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return true, nil
+	}
+	// primeira vez: cria a linha; se outra réplica criou antes, não somos o líder
+	res = l.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.Lease{Name: l.name, Holder: l.holder, ExpiresAt: now.Add(l.ttl)})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// Release libera o arrendamento (ex.: no desligamento) para outra réplica assumir já.
+func (l *Lease) Release() error {
+	return l.db.Model(&models.Lease{}).
+		Where("name = ? AND holder = ?", l.name, l.holder).
+		Update("expires_at", time.Now().Add(-time.Second)).Error
+}
+
+// RecordRun registra no banco o resultado da última execução do líder (visível por qualquer réplica).
+func (l *Lease) RecordRun(at time.Time, runErr error) error {
+	msg := ""
+	if runErr != nil {
+		msg = runErr.Error()
+	}
+	return l.db.Model(&models.Lease{}).
+		Where("name = ? AND holder = ?", l.name, l.holder).
+		Updates(map[string]interface{}{"last_run_at": at, "last_error": msg}).Error
+}
+
+// Current devolve o estado do arrendamento no banco (quem lidera agora).
+func (l *Lease) Current() (models.Lease, error) {
+	var cur models.Lease
+	err := l.db.First(&cur, "name = ?", l.name).Error
+	return cur, err
+}
+
+func holderID() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b))
+}
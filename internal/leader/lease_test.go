@@ -0,0 +1,52 @@
+package leader
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"goTasks/internal/models"
+)
+
+func TestLeaseSingleLeaderAndFailover(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&models.Lease{}); err != nil {
+		t.Fatal(err)
+	}
+
+	a := NewLease(db, "scheduler", time.Minute)
+	b := NewLease(db, "scheduler", time.Minute)
+
+	if ok, err := a.TryAcquire(); err != nil || !ok {
+		t.Fatalf("a should lead: ok=%v err=%v", ok, err)
+	}
+	if ok, _ := b.TryAcquire(); ok {
+		t.Fatal("b must not lead while a holds the lease")
+	}
+	if ok, _ := a.TryAcquire(); !ok {
+		t.Fatal("a should renew its own lease")
+	}
+
+	// a some sem liberar: b assume depois que o arrendamento vence
+	db.Model(&models.Lease{}).Where("name = ?", "scheduler").Update("expires_at", time.Now().Add(-time.Second))
+	if ok, _ := b.TryAcquire(); !ok {
+		t.Fatal("b should take over an expired lease")
+	}
+	if ok, _ := a.TryAcquire(); ok {
+		t.Fatal("a lost the lease and must not lead")
+	}
+
+	if err := b.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := a.TryAcquire(); !ok {
+		t.Fatal("a should lead right after b releases")
+	}
+}
@@ -0,0 +1,146 @@
+package mail
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"goTasks/internal/models"
+)
+
+const (
+	maxAttempts = 6
+	batchSize   = 50
+	sendTimeout = 30 * time.Second
+)
+
+// Queue persiste e-mails em email_deliveries e os envia com retentativas exponenciais.
+type Queue struct {
+	db     *gorm.DB
+	sender Sender
+	stop   chan struct{}
+}
+
+func NewQueue(db *gorm.DB, sender Sender) *Queue {
+	return &Queue{db: db, sender: sender, stop: make(chan struct{})}
+}
+
+// Enqueue agenda a mensagem; notBefore permite adiar (ex.: fim do horário de silêncio).
+// Com dedupeKey != "", um segundo enfileiramento com a mesma chave é ignorado.
+func (q *Queue) Enqueue(userID, taskID uint, kind string, msg Message, notBefore time.Time, dedupeKey string) error {
+	if notBefore.IsZero() {
+		notBefore = time.Now()
+	}
+	var key *string
+	if dedupeKey != "" {
+		key = &dedupeKey
+	}
+	d := models.EmailDelivery{
+		UserID:        userID,
+		TaskID:        taskID,
+		Kind:          kind,
+		ToAddress:     msg.To,
+		Subject:       msg.Subject,
+		TextBody:      msg.Text,
+		HTMLBody:      msg.HTML,
+		Status:        models.EmailPending,
+		NextAttemptAt: notBefore,
+		DedupeKey:     key,
+	}
+	return q.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&d).Error
+}
+
+// Sent informa se já existe e-mail do tipo para a tarefa desde since (dedupe do canal de e-mail).
+func (q *Queue) Sent(userID, taskID uint, kind string, since time.Time) (bool, error) {
+	var count int64
+	err := q.db.Model(&models.EmailDelivery{}).
+		Where("user_id = ? AND task_id = ? AND kind = ? AND created_at >= ?", userID, taskID, kind, since).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (q *Queue) Start() {
+	go func() {
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := q.processDue(context.Background()); err != nil {
+					log.Printf("mail queue error: %v", err)
+				}
+			case <-q.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (q *Queue) Stop() {
+	close(q.stop)
+}
+
+// processDue envia os itens vencidos; devolve quantos foram entregues.
+func (q *Queue) processDue(ctx context.Context) (int, error) {
+	var items []models.EmailDelivery
+	if err := q.db.
+		Where("status = ? AND next_attempt_at <= ?", models.EmailPending, time.Now()).
+		Order("next_attempt_at").
+		Limit(batchSize).
+		Find(&items).Error; err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, d := range items {
+		// reserva o item incrementando attempts; outra réplica que chegue junto perde a corrida
+		res := q.db.Model(&models.EmailDelivery{}).
+			Where("id = ? AND status = ? AND attempts = ?", d.ID, models.EmailPending, d.Attempts).
+			Updates(map[string]interface{}{
+				"attempts":        d.Attempts + 1,
+				"next_attempt_at": time.Now().Add(backoff(d.Attempts + 1)),
+			})
+		if res.Error != nil {
+			return sent, res.Error
+		}
+		if res.RowsAffected == 0 {
+			continue
+		}
+		d.Attempts++
+
+		sctx, cancel := context.WithTimeout(ctx, sendTimeout)
+		err := q.sender.Send(sctx, Message{To: d.ToAddress, Subject: d.Subject, Text: d.TextBody, HTML: d.HTMLBody})
+		cancel()
+
+		updates := map[string]interface{}{}
+		if err == nil {
+			now := time.Now()
+			updates["status"] = models.EmailSent
+			updates["sent_at"] = &now
+			updates["last_error"] = ""
+			sent++
+		} else {
+			updates["last_error"] = err.Error()
+			if d.Attempts >= maxAttempts {
+				updates["status"] = models.EmailFailed
+			}
+			log.Printf("mail delivery %d attempt %d failed: %v", d.ID, d.Attempts, err)
+		}
+		if err := q.db.Model(&models.EmailDelivery{}).Where("id = ?", d.ID).Updates(updates).Error; err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+// backoff: 1m, 2m, 4m, ... limitado a 6h.
+func backoff(attempt int) time.Duration {
+	d := time.Minute << (attempt - 1)
+	if d > 6*time.Hour || d <= 0 {
+		d = 6 * time.Hour
+	}
+	return d
+}
@@ -0,0 +1,170 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"goTasks/internal/models"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&models.EmailDelivery{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestQueueDeliversToFileSink(t *testing.T) {
+	db := newTestDB(t)
+	dir := t.TempDir()
+	q := NewQueue(db, NewFileSender(dir, "goTasks <no-reply@gotasks.local>"))
+
+	msg, err := RenderNotification("ana@example.com", NotificationEmail{UserName: "Ana", Message: "Tarefa atrasada: Relatório", TaskID: 7, TaskTitle: "Relatório", AppURL: "http://app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Enqueue(1, 7, models.NotificationOverdue, msg, time.Time{}, "overdue:7:1"); err != nil {
+		t.Fatal(err)
+	}
+	// mesma chave: ignorado
+	if err := q.Enqueue(1, 7, models.NotificationOverdue, msg, time.Time{}, "overdue:7:1"); err != nil {
+		t.Fatal(err)
+	}
+	sent, err := q.processDue(context.Background())
+	if err != nil || sent != 1 {
+		t.Fatalf("sent=%d err=%v", sent, err)
+	}
+
+	files, _ := os.ReadDir(dir)
+	if len(files) != 1 {
+		t.Fatalf("expected 1 .eml, got %d", len(files))
+	}
+	raw, _ := os.ReadFile(dir + "/" + files[0].Name())
+	for _, want := range []string{"To: ana@example.com", "multipart/alternative", "text/plain", "text/html"} {
+		if !strings.Contains(string(raw), want) {
+			t.Errorf("missing %q in message", want)
+		}
+	}
+
+	var d models.EmailDelivery
+	db.First(&d)
+	if d.Status != models.EmailSent || d.SentAt == nil {
+		t.Fatalf("unexpected delivery state: %+v", d)
+	}
+}
+
+type failingSender struct{}
+
+func (failingSender) Send(context.Context, Message) error { return errors.New("smtp down") }
+
+func TestQueueRetriesThenFails(t *testing.T) {
+	db := newTestDB(t)
+	q := NewQueue(db, failingSender{})
+	if err := q.Enqueue(1, 0, "digest", Message{To: "ana@example.com", Subject: "x", Text: "x"}, time.Time{}, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= maxAttempts; i++ {
+		if _, err := q.processDue(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		var d models.EmailDelivery
+		db.First(&d)
+		if d.Attempts != i || d.LastError != "smtp down" {
+			t.Fatalf("attempt %d: %+v", i, d)
+		}
+		if i < maxAttempts && (d.Status != models.EmailPending || !d.NextAttemptAt.After(time.Now())) {
+			t.Fatalf("attempt %d should be rescheduled: %+v", i, d)
+		}
+		// antecipa a próxima tentativa
+		db.Model(&d).Update("next_attempt_at", time.Now().Add(-time.Second))
+	}
+	var d models.EmailDelivery
+	db.First(&d)
+	if d.Status != models.EmailFailed {
+		t.Fatalf("expected failed after %d attempts, got %+v", maxAttempts, d)
+	}
+}
+
+// fakeSMTP é um servidor SMTP mínimo que guarda o DATA recebido.
+func fakeSMTP(t *testing.T) (addr string, data chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	data = make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		w := func(s string) { conn.Write([]byte(s + "\r\n")) }
+		w("220 fake ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				w("250 fake")
+			case strings.HasPrefix(cmd, "DATA"):
+				w("354 go ahead")
+				var b strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					b.WriteString(l)
+				}
+				data <- b.String()
+				w("250 queued")
+			case strings.HasPrefix(cmd, "QUIT"):
+				w("221 bye")
+				return
+			default:
+				w("250 ok")
+			}
+		}
+	}()
+	return ln.Addr().String(), data
+}
+
+func TestSMTPSender(t *testing.T) {
+	addr, data := fakeSMTP(t)
+	host, port, _ := net.SplitHostPort(addr)
+	s := NewSMTPSender(host, port, "", "", "goTasks <no-reply@gotasks.local>")
+	if err := s.Send(context.Background(), Message{To: "ana@example.com", Subject: "Olá", Text: "corpo", HTML: "<p>corpo</p>"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-data:
+		if !strings.Contains(got, "To: ana@example.com") || !strings.Contains(got, "Subject: =?utf-8?q?Ol=C3=A1?=") {
+			t.Fatalf("unexpected message:\n%s", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("smtp server received nothing")
+	}
+}
@@ -0,0 +1,98 @@
+package mail
+
+import (
+	"bytes"
+	htmltpl "html/template"
+	texttpl "text/template"
+	"time"
+)
+
+// NotificationEmail alimenta o template de uma notificação avulsa.
+type NotificationEmail struct {
+	UserName  string
+	Message   string
+	TaskID    uint
+	TaskTitle string
+	AppURL    string
+}
+
+// DigestEmail alimenta o resumo matinal de tarefas atrasadas e do dia.
+type DigestEmail struct {
+	UserName string
+	Date     string // data local do usuário, ex.: "19/10/2026"
+	Overdue  []DigestTask
+	DueToday []DigestTask
+	AppURL   string
+}
+
+type DigestTask struct {
+	ID      uint
+	Title   string
+	DueDate time.Time // já no fuso do usuário
+}
+
+var (
+	notificationText = texttpl.Must(texttpl.New("notification").Parse(`Olá, {{.UserName}}!
+
+{{.Message}}
+
+Abrir tarefa: {{.AppURL}}/tasks?task={{.TaskID}}
+
+— goTasks
+`))
+	notificationHTML = htmltpl.Must(htmltpl.New("notification").Parse(`<!doctype html>
+<html><body style="font-family:sans-serif">
+<p>Olá, {{.UserName}}!</p>
+<p>{{.Message}}</p>
+<p><a href="{{.AppURL}}/tasks?task={{.TaskID}}">Abrir “{{.TaskTitle}}”</a></p>
+<p style="color:#888">— goTasks</p>
+</body></html>`))
+
+	digestText = texttpl.Must(texttpl.New("digest").Parse(`Olá, {{.UserName}}! Seu resumo de {{.Date}}:
+{{if .Overdue}}
+Atrasadas:
+{{range .Overdue}}- {{.Title}} (venceu {{.DueDate.Format "02/01 15:04"}})
+{{end}}{{end}}{{if .DueToday}}
+Vencem hoje:
+{{range .DueToday}}- {{.Title}} ({{.DueDate.Format "15:04"}})
+{{end}}{{end}}
+{{.AppURL}}/tasks
+
+— goTasks
+`))
+	digestHTML = htmltpl.Must(htmltpl.New("digest").Parse(`<!doctype html>
+<html><body style="font-family:sans-serif">
+<p>Olá, {{.UserName}}! Seu resumo de {{.Date}}:</p>
+{{if .Overdue}}<h3>Atrasadas</h3><ul>
+{{range .Overdue}}<li><a href="{{$.AppURL}}/tasks?task={{.ID}}">{{.Title}}</a> — venceu {{.DueDate.Format "02/01 15:04"}}</li>
+{{end}}</ul>{{end}}
+{{if .DueToday}}<h3>Vencem hoje</h3><ul>
+{{range .DueToday}}<li><a href="{{$.AppURL}}/tasks?task={{.ID}}">{{.Title}}</a> — {{.DueDate.Format "15:04"}}</li>
+{{end}}</ul>{{end}}
+<p style="color:#888">— goTasks</p>
+</body></html>`))
+)
+
+// RenderNotification gera assunto e corpos de uma notificação.
+func RenderNotification(to string, d NotificationEmail) (Message, error) {
+	var text, html bytes.Buffer
+	if err := notificationText.Execute(&text, d); err != nil {
+		return Message{}, err
+	}
+	if err := notificationHTML.Execute(&html, d); err != nil {
+		return Message{}, err
+	}
+	return Message{To: to, Subject: "[goTasks] " + d.Message, Text: text.String(), HTML: html.String()}, nil
+}
+
+// RenderDigest gera o e-mail de resumo diário.
+func RenderDigest(to string, d DigestEmail) (Message, error) {
+	var text, html bytes.Buffer
+	if err := digestText.Execute(&text, d); err != nil {
+		return Message{}, err
+	}
+	if err := digestHTML.Execute(&html, d); err != nil {
+		return Message{}, err
+	}
+	return Message{To: to, Subject: "[goTasks] Resumo de " + d.Date, Text: text.String(), HTML: html.String()}, nil
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// ChatChannel é uma URL de incoming webhook do Slack/Mattermost de um usuário.
+type ChatChannel struct {
+	ID     uint `gorm:"primaryKey" json:"id"`
+	UserID uint `gorm:"index" json:"userId"`
+	// ProjectID: canal do projeto, que recebe os eventos de todas as tarefas dele (nil = canal pessoal)
+	ProjectID *uint  `gorm:"index" json:"projectId,omitempty"`
+	Name      string `json:"name"`
+	URL       string `json:"-"` // contém o token do webhook: não é devolvida pela API
+	// Notifications: recebe as notificações cujo canal "chat" está ativo nas preferências
+	Notifications bool `json:"notifications"`
+	// TaskEvents: eventos de tarefa encaminhados (task.created, task.updated, task.deleted)
+	TaskEvents []string  `gorm:"serializer:json" json:"taskEvents"`
+	Active     bool      `gorm:"default:true" json:"active"`
+	LastError  string    `json:"lastError,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
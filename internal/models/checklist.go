@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// ChecklistItem é um passo simples dentro de uma tarefa, em ordem de Position.
+type ChecklistItem struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	TaskID    uint      `gorm:"index:idx_checklist_task_position,priority:1" json:"taskId"`
+	Text      string    `gorm:"type:varchar(500)" json:"text"`
+	Done      bool      `gorm:"default:false" json:"done"`
+	Position  int       `gorm:"index:idx_checklist_task_position,priority:2" json:"position"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+const (
+	MaxChecklistItems = 100
+	// MaxSubtaskDepth: níveis de subtarefa abaixo de uma tarefa raiz
+	MaxSubtaskDepth = 3
+)
+
+// Progress conta itens concluídos de um total (ex.: 3/5).
+type Progress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// TaskProgress resume as subtarefas diretas e o checklist de uma tarefa.
+type TaskProgress struct {
+	Subtasks  Progress `json:"subtasks"`
+	Checklist Progress `json:"checklist"`
+}
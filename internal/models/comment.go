@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Comment struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
@@ -11,4 +15,6 @@ type Comment struct {
 	Content   string    `json:"content"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
-}
\ No newline at end of file
+	// DeletedAt: o comentário vai para a lixeira junto com a tarefa
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Status de entrega de e-mail
+const (
+	EmailPending = "pending"
+	EmailSent    = "sent"
+	EmailFailed  = "failed"
+)
+
+// EmailDelivery é um item da fila de e-mails, reprocessado com backoff até EmailSent ou EmailFailed.
+type EmailDelivery struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	UserID        uint       `gorm:"index;uniqueIndex:idx_email_dedupe,priority:1" json:"userId"`
+	TaskID        uint       `json:"taskId,omitempty"`             // 0 para digests
+	Kind          string     `gorm:"type:varchar(32)" json:"kind"` // tipo da notificação ou "digest"
+	ToAddress     string     `json:"to"`
+	Subject       string     `json:"subject"`
+	TextBody      string     `json:"-"`
+	HTMLBody      string     `json:"-"`
+	Status        string     `gorm:"type:varchar(16);index:idx_email_due,priority:1" json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"index:idx_email_due,priority:2" json:"nextAttemptAt"`
+	LastError     string     `json:"lastError,omitempty"`
+	DedupeKey     *string    `gorm:"type:varchar(128);uniqueIndex:idx_email_dedupe,priority:2" json:"-"` // NULL = sem dedupe
+	SentAt        *time.Time `json:"sentAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Label é uma etiqueta pessoal (sem projeto; nome único por dono) ou de um projeto (nome
+// único no projeto, gerida pelos editores dele). OwnerID é sempre quem a criou.
+type Label struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	OwnerID   uint      `gorm:"index;uniqueIndex:idx_labels_personal_name,priority:1,where:project_id IS NULL" json:"ownerId"`
+	ProjectID *uint     `gorm:"uniqueIndex:idx_labels_project_name,priority:1,where:project_id IS NOT NULL" json:"projectId,omitempty"`
+	Name      string    `gorm:"type:varchar(50);uniqueIndex:idx_labels_personal_name,priority:2;uniqueIndex:idx_labels_project_name,priority:2" json:"name"`
+	Color     string    `gorm:"type:varchar(7)" json:"color"` // #rrggbb
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// DefaultLabelColor é usada quando a etiqueta é criada sem cor.
+const DefaultLabelColor = "#9e9e9e"
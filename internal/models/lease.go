@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Lease é a posse temporária de um job em background (eleição de líder entre réplicas).
+type Lease struct {
+	Name      string     `gorm:"primaryKey;type:varchar(64)" json:"name"`
+	Holder    string     `gorm:"type:varchar(128)" json:"holder"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	LastRunAt *time.Time `json:"lastRunAt,omitempty"` // última execução do líder
+	LastError string     `json:"lastError,omitempty"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
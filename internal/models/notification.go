@@ -1,13 +1,65 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tipos de notificação
+const (
+	NotificationOverdue       = "overdue"
+	NotificationDueSoon       = "due_soon"
+	NotificationComment       = "comment"
+	NotificationMention       = "mention"
+	NotificationStatusChanged = "status_changed"
+	NotificationAssigned      = "assigned"
+	NotificationReminder      = "reminder"
+)
+
+// NotificationTypes lista os tipos conhecidos (usado na validação de preferências).
+var NotificationTypes = []string{
+	NotificationOverdue, NotificationDueSoon, NotificationComment,
+	NotificationMention, NotificationStatusChanged, NotificationAssigned,
+	NotificationReminder,
+}
+
+// unreadKeySuffix marca chaves de notificações agrupáveis enquanto não lidas.
+const unreadKeySuffix = ":unread"
+
+// UnreadDedupeKey é a chave da notificação agrupável aberta de um tipo numa tarefa;
+// ao ser lida a chave é liberada e o próximo evento abre outra.
+func UnreadDedupeKey(typ string, taskID uint) string {
+	return fmt.Sprintf("%s:%d%s", typ, taskID, unreadKeySuffix)
+}
+
+// WindowDedupeKey identifica a notificação de um tipo numa tarefa dentro de uma janela de tempo.
+func WindowDedupeKey(typ string, taskID uint, at time.Time, window time.Duration) string {
+	return fmt.Sprintf("%s:%d:%d", typ, taskID, at.Unix()/int64(window/time.Second))
+}
+
+// DueDedupeKey identifica o lembrete de um vencimento: um por tarefa e data (nova data = novo lembrete).
+func DueDedupeKey(typ string, taskID uint, due time.Time) string {
+	return fmt.Sprintf("%s:%d:%d", typ, taskID, due.Unix())
+}
+
+// ReleaseUnreadKey é a expressão de UPDATE que libera chaves de agrupamento ao marcar como lida.
+func ReleaseUnreadKey() interface{} {
+	return gorm.Expr("CASE WHEN dedupe_key LIKE ? THEN NULL ELSE dedupe_key END", "%"+unreadKeySuffix)
+}
 
 type Notification struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
-	UserID    uint      `json:"userId"`
+	UserID    uint      `gorm:"index;uniqueIndex:idx_notifications_dedupe,priority:1" json:"userId"`
 	TaskID    uint      `json:"taskId"`
 	Type      string    `json:"type"`    // e.g., "due_soon", "overdue", "comment"
 	Message   string    `json:"message"` // texto amigável
 	Read      bool      `json:"read"`    // lida?
+	Count     int       `gorm:"default:1" json:"count"` // eventos agrupados (ex.: 3 novos comentários)
+	ActorID   *uint     `json:"actorId,omitempty"`      // quem causou o último evento
+	// DedupeKey torna a criação idempotente entre réplicas (NULL = sem dedupe)
+	DedupeKey *string   `gorm:"type:varchar(128);uniqueIndex:idx_notifications_dedupe,priority:2" json:"-"`
 	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
 }
\ No newline at end of file
@@ -0,0 +1,121 @@
+package models
+
+import "time"
+
+// Canais de entrega de notificações
+const (
+	ChannelInApp   = "in_app"
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+	ChannelChat    = "chat" // Slack/Mattermost
+)
+
+// NotificationChannels lista os canais aceitos nas preferências.
+var NotificationChannels = []string{ChannelInApp, ChannelEmail, ChannelWebhook, ChannelChat}
+
+// DefaultDueSoonLead é a antecedência padrão dos lembretes due_soon.
+const DefaultDueSoonLead = 24 * time.Hour
+
+type NotificationPreference struct {
+	UserID uint `gorm:"primaryKey" json:"userId"`
+	// Channels: tipo de notificação -> canais habilitados; "*" vale para tipos não listados
+	Channels           map[string][]string `gorm:"serializer:json" json:"channels"`
+	DueSoonLeadMinutes int                 `json:"dueSoonLeadMinutes"`
+	QuietHoursStart    string              `gorm:"type:varchar(5)" json:"quietHoursStart"` // "22:00"; vazio = sem silêncio
+	QuietHoursEnd      string              `gorm:"type:varchar(5)" json:"quietHoursEnd"`   // "07:00"
+	Timezone           string              `gorm:"type:varchar(64)" json:"timezone"`       // IANA, ex.: "America/Sao_Paulo"
+	DigestEnabled      bool                `json:"digestEnabled"`                          // resumo matinal por e-mail
+	DigestHour         int                 `gorm:"default:8" json:"digestHour"`            // hora local do resumo (0-23)
+	LastDigestDate     string              `gorm:"type:varchar(10)" json:"-"`              // data local do último resumo
+	UpdatedAt          time.Time           `json:"updatedAt"`
+}
+
+// DefaultNotificationPreference: tudo in-app, lembrete 24h antes, sem horário de silêncio nem resumo.
+func DefaultNotificationPreference(userID uint) NotificationPreference {
+	return NotificationPreference{
+		UserID:             userID,
+		Channels:           map[string][]string{"*": {ChannelInApp}},
+		DueSoonLeadMinutes: int(DefaultDueSoonLead / time.Minute),
+		Timezone:           "UTC",
+		DigestHour:         8,
+	}
+}
+
+// Wants diz se o usuário quer receber o tipo de notificação pelo canal.
+func (p NotificationPreference) Wants(typ, channel string) bool {
+	chans, ok := p.Channels[typ]
+	if !ok {
+		chans, ok = p.Channels["*"]
+		if !ok {
+			return channel == ChannelInApp
+		}
+	}
+	for _, c := range chans {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+func (p NotificationPreference) DueSoonLead() time.Duration {
+	if p.DueSoonLeadMinutes <= 0 {
+		return DefaultDueSoonLead
+	}
+	return time.Duration(p.DueSoonLeadMinutes) * time.Minute
+}
+
+// Location devolve o fuso do usuário (UTC se inválido).
+func (p NotificationPreference) Location() *time.Location {
+	if p.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// InQuietHours diz se t cai no horário de silêncio (no fuso do usuário); aceita janelas que viram a meia-noite.
+func (p NotificationPreference) InQuietHours(t time.Time) bool {
+	start, ok1 := parseClock(p.QuietHoursStart)
+	end, ok2 := parseClock(p.QuietHoursEnd)
+	if !ok1 || !ok2 || start == end {
+		return false
+	}
+	local := t.In(p.Location())
+	now := local.Hour()*60 + local.Minute()
+	if start < end {
+		return now >= start && now < end
+	}
+	return now >= start || now < end
+}
+
+// QuietHoursEndAfter devolve o próximo fim do horário de silêncio após t (t se não estiver em silêncio).
+func (p NotificationPreference) QuietHoursEndAfter(t time.Time) time.Time {
+	if !p.InQuietHours(t) {
+		return t
+	}
+	end, _ := parseClock(p.QuietHoursEnd)
+	local := t.In(p.Location())
+	next := time.Date(local.Year(), local.Month(), local.Day(), end/60, end%60, 0, 0, local.Location())
+	if !next.After(local) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// ValidClock valida horários "HH:MM".
+func ValidClock(s string) bool {
+	_, ok := parseClock(s)
+	return ok
+}
+
+func parseClock(s string) (int, bool) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Papéis de usuário: admin é o super-admin da instância; org_admin administra só a própria organização.
+const (
+	RoleUser     = "user"
+	RoleOrgAdmin = "org_admin"
+	RoleAdmin    = "admin"
+)
+
+// OrgRoles são os papéis que um org_admin pode atribuir.
+var OrgRoles = []string{RoleUser, RoleOrgAdmin}
+
+// Organization é o limite de isolamento (tenant): usuários, projetos e tarefas pertencem a uma só.
+type Organization struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"type:varchar(100);not null" json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
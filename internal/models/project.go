@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Papéis de um membro no projeto
+const (
+	ProjectOwner  = "owner"  // gerencia o projeto, os membros e todas as tarefas
+	ProjectEditor = "editor" // cria e edita tarefas
+	ProjectViewer = "viewer" // vê e comenta
+)
+
+var ProjectRoles = []string{ProjectOwner, ProjectEditor, ProjectViewer}
+
+// Project agrupa tarefas e define quem as vê e edita.
+type Project struct {
+	ID          uint            `gorm:"primaryKey" json:"id"`
+	Name        string          `gorm:"type:varchar(100)" json:"name"`
+	Description string          `json:"description"`
+	OrgID       uint            `gorm:"index" json:"orgId"`
+	Members     []ProjectMember `json:"members,omitempty"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	UpdatedAt   time.Time       `json:"updatedAt"`
+}
+
+type ProjectMember struct {
+	ProjectID uint      `gorm:"primaryKey;autoIncrement:false" json:"projectId"`
+	UserID    uint      `gorm:"primaryKey;autoIncrement:false;index" json:"userId"`
+	User      User      `json:"user"`
+	Role      string    `gorm:"type:varchar(16)" json:"role"`
+	CreatedAt time.Time `json:"createdAt"`
+}
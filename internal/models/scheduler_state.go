@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// SchedulerState guarda a marca d'água de um job incremental (até onde já foi processado).
+type SchedulerState struct {
+	Name      string    `gorm:"primaryKey;type:varchar(64)" json:"name"`
+	Watermark time.Time `json:"watermark"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
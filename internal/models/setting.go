@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// Setting é uma configuração da instância ajustável pelo admin em tempo de execução.
+type Setting struct {
+	Key       string    `gorm:"primaryKey;type:varchar(64)" json:"key"`
+	Value     string    `json:"value"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type TaskStatus string
 
@@ -10,14 +14,47 @@ const (
 	StatusDone  TaskStatus = "done"
 )
 
+// Task: os índices (owner_id, campo) cobrem a listagem por dono nas ordenações de ?sort=.
 type Task struct {
-	ID          uint       `gorm:"primaryKey" json:"id"`
+	ID          uint       `gorm:"primaryKey;index:idx_tasks_due_id,priority:2" json:"id"`
 	Title       string     `json:"title"`
 	Description string     `json:"description"`
-	Status      TaskStatus `gorm:"type:varchar(16)" json:"status"`
-	DueDate     *time.Time `json:"dueDate,omitempty"`
-	OwnerID     uint       `json:"ownerId"`
-	Owner       User       `json:"owner"`
-	CreatedAt   time.Time  `json:"createdAt"`
-	UpdatedAt   time.Time  `json:"updatedAt"`
-}
\ No newline at end of file
+	Status      TaskStatus `gorm:"type:varchar(16);index:idx_tasks_board,priority:2" json:"status"`
+	// StatusCategory: categoria do status no workflow do projeto, gravada junto com o status
+	StatusCategory StatusCategory `gorm:"type:varchar(16);index" json:"statusCategory"`
+	Priority       Priority       `gorm:"default:0;index:idx_tasks_owner_priority,priority:2" json:"priority"`
+	DueDate        *time.Time     `gorm:"index:idx_tasks_due_id,priority:1;index:idx_tasks_owner_due,priority:2" json:"dueDate,omitempty"`
+	OwnerID        uint           `gorm:"index:idx_tasks_owner_priority,priority:1;index:idx_tasks_owner_due,priority:1;index:idx_tasks_owner_created,priority:1" json:"ownerId"`
+	Owner          User           `json:"owner"`
+	// SeriesID/Occurrence: posição na série de uma tarefa recorrente (nil = avulsa)
+	SeriesID   *uint       `gorm:"uniqueIndex:idx_tasks_series_occurrence,priority:1" json:"seriesId,omitempty"`
+	Occurrence int         `gorm:"uniqueIndex:idx_tasks_series_occurrence,priority:2" json:"occurrence,omitempty"`
+	Series     *TaskSeries `json:"series,omitempty"`
+	Labels     []Label     `gorm:"many2many:task_labels" json:"labels"`
+	// Assignees editam a tarefa; Watchers acompanham (ver e comentar)
+	Assignees []User `gorm:"many2many:task_assignees" json:"assignees"`
+	Watchers  []User `gorm:"many2many:task_watchers" json:"watchers"`
+	// OrgID: organização da tarefa; toda consulta de tarefas é filtrada por ela
+	OrgID uint `gorm:"index" json:"orgId"`
+	// ProjectID: projeto da tarefa (nil = tarefa pessoal do dono)
+	ProjectID *uint `gorm:"index;index:idx_tasks_board,priority:1" json:"projectId,omitempty"`
+	// Position: ordem manual na coluna do quadro (fracionária: mover grava só a tarefa movida)
+	Position float64 `gorm:"index:idx_tasks_board,priority:3" json:"position"`
+	// ParentID: tarefa mãe de uma subtarefa (nil = raiz)
+	ParentID  *uint         `gorm:"index" json:"parentId,omitempty"`
+	Progress  *TaskProgress `gorm:"-" json:"progress,omitempty"` // calculado na leitura
+	CreatedAt time.Time     `gorm:"index:idx_tasks_owner_created,priority:2" json:"createdAt"`
+	UpdatedAt time.Time     `gorm:"index" json:"updatedAt"`
+	// Version: sobe a cada edição; é o ETag da tarefa e a condição do UPDATE (controle otimista)
+	Version int `gorm:"not null;default:1" json:"version"`
+	// DeletedAt: na lixeira desde então (as consultas comuns não veem a tarefa)
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deletedAt,omitempty"`
+}
+
+// BeforeSave usa o workflow padrão quando quem grava não informou a categoria do status.
+func (t *Task) BeforeSave(tx *gorm.DB) error {
+	if t.StatusCategory == "" && t.Status != "" {
+		t.StatusCategory = DefaultWorkflow().Category(t.Status)
+	}
+	return nil
+}
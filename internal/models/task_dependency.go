@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// TaskDependency é uma aresta do grafo de dependências: TaskID é bloqueada por BlockerID.
+type TaskDependency struct {
+	TaskID    uint      `gorm:"primaryKey;autoIncrement:false" json:"taskId"`
+	BlockerID uint      `gorm:"primaryKey;autoIncrement:false;index" json:"blockerId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
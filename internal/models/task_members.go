@@ -0,0 +1,37 @@
+package models
+
+import "gorm.io/gorm"
+
+// Tabelas de junção de Task.Assignees e Task.Watchers
+const (
+	TaskAssigneesTable = "task_assignees"
+	TaskWatchersTable  = "task_watchers"
+)
+
+// TaskMembers devolve os responsáveis e os observadores de uma tarefa.
+func TaskMembers(db *gorm.DB, taskID uint) (assignees, watchers []uint, err error) {
+	if err = db.Table(TaskAssigneesTable).Where("task_id = ?", taskID).Pluck("user_id", &assignees).Error; err != nil {
+		return nil, nil, err
+	}
+	err = db.Table(TaskWatchersTable).Where("task_id = ?", taskID).Pluck("user_id", &watchers).Error
+	return assignees, watchers, err
+}
+
+// AssigneesByTask agrupa os responsáveis de várias tarefas numa consulta.
+func AssigneesByTask(db *gorm.DB, taskIDs []uint) (map[uint][]uint, error) {
+	out := map[uint][]uint{}
+	if len(taskIDs) == 0 {
+		return out, nil
+	}
+	var rows []struct {
+		TaskID uint
+		UserID uint
+	}
+	if err := db.Table(TaskAssigneesTable).Select("task_id, user_id").Where("task_id IN ?", taskIDs).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		out[r.TaskID] = append(out[r.TaskID], r.UserID)
+	}
+	return out, nil
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// MaxRemindersPerTask limita quantos lembretes cada usuário cria numa tarefa.
+const MaxRemindersPerTask = 20
+
+// TaskReminder é um lembrete de uma tarefa: num horário absoluto (At) ou
+// OffsetMinutes antes do DueDate. FireAt é o horário efetivo (nil = tarefa sem vencimento).
+type TaskReminder struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	TaskID        uint       `gorm:"index" json:"taskId"`
+	UserID        uint       `gorm:"index" json:"userId"` // quem recebe
+	At            *time.Time `json:"at,omitempty"`
+	OffsetMinutes *int       `json:"offsetMinutes,omitempty"`
+	FireAt        *time.Time `gorm:"index:idx_reminders_pending,priority:2" json:"fireAt"`
+	FiredAt       *time.Time `gorm:"index:idx_reminders_pending,priority:1" json:"firedAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+// Schedule recalcula FireAt a partir do vencimento; devolve true se mudou.
+func (r *TaskReminder) Schedule(due *time.Time) bool {
+	var next *time.Time
+	switch {
+	case r.At != nil:
+		next = r.At
+	case r.OffsetMinutes != nil && due != nil:
+		t := due.Add(-time.Duration(*r.OffsetMinutes) * time.Minute)
+		next = &t
+	}
+	if (next == nil) == (r.FireAt == nil) && (next == nil || next.Equal(*r.FireAt)) {
+		return false
+	}
+	r.FireAt = next
+	return true
+}
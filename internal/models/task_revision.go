@@ -0,0 +1,51 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Origem de uma revisão
+const (
+	SourceAPI       = "api"
+	SourceScheduler = "scheduler" // rotinas do servidor, ex.: a próxima ocorrência de uma série
+	SourceAI        = "ai"        // sugestão da IA aplicada por uma rota do servidor (nunca informada pelo cliente)
+)
+
+// TaskSnapshot é o estado dos campos rastreados de uma tarefa; listas de ids vêm ordenadas.
+type TaskSnapshot struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Status      TaskStatus `json:"status"`
+	Priority    Priority   `json:"priority"`
+	DueDate     *time.Time `json:"dueDate"`
+	OwnerID     uint       `json:"ownerId"`
+	ProjectID   *uint      `json:"projectId"`
+	ParentID    *uint      `json:"parentId"`
+	LabelIDs    []uint     `json:"labelIds"`
+	AssigneeIDs []uint     `json:"assigneeIds"`
+	WatcherIDs  []uint     `json:"watcherIds"`
+}
+
+// FieldChange é a mudança de um campo, com os valores no formato da API.
+type FieldChange struct {
+	Field string          `json:"field"`
+	From  json.RawMessage `json:"from"`
+	To    json.RawMessage `json:"to"`
+}
+
+// TaskRevision é uma entrada do histórico da tarefa (só inserção; sobrevive à tarefa).
+// State guarda o estado depois da mudança, usado para restaurar a revisão.
+type TaskRevision struct {
+	ID      uint          `gorm:"primaryKey" json:"id"`
+	TaskID  uint          `gorm:"index:idx_task_revisions_task,priority:1" json:"taskId"`
+	OrgID   uint          `gorm:"index" json:"orgId"`
+	ActorID *uint         `json:"actorId,omitempty"` // nil = o próprio sistema
+	Actor   *User         `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+	Source  string        `gorm:"type:varchar(16)" json:"source"`
+	Changes []FieldChange `gorm:"serializer:json" json:"changes"`
+	State   TaskSnapshot  `gorm:"serializer:json" json:"state"`
+	// RestoredFrom: revisão restaurada por esta
+	RestoredFrom *uint     `json:"restoredFrom,omitempty"`
+	CreatedAt    time.Time `gorm:"index:idx_task_revisions_task,priority:2" json:"createdAt"`
+}
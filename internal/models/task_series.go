@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// TaskSeries liga as ocorrências de uma tarefa recorrente. Title e Description são o
+// modelo das próximas ocorrências ("todas as futuras"); cada tarefa ainda pode ser editada sozinha.
+type TaskSeries struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	OwnerID  uint   `gorm:"index" json:"ownerId"`
+	RRule    string `gorm:"column:rrule;type:varchar(255)" json:"rrule"`
+	Timezone string `gorm:"type:varchar(64)" json:"timezone"` // fuso em que BYDAY/BYMONTHDAY são avaliados
+	// Start é o DTSTART: vencimento da ocorrência StartOccurrence (muda quando a regra é reancorada)
+	Start           time.Time  `json:"start"`
+	StartOccurrence int        `json:"startOccurrence"`
+	Occurrences     int        `json:"occurrences"` // maior ocorrência já gerada
+	Title           string     `json:"title"`
+	Description     string     `json:"description"`
+	EndedAt         *time.Time `json:"endedAt,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
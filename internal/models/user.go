@@ -8,6 +8,8 @@ type User struct {
 	Email        string    `gorm:"uniqueIndex" json:"email"`
 	PasswordHash string    `json:"-"`
 	Role         string    `json:"role" gorm:"type:varchar(16);default:user"`
+	OrgID        uint      `gorm:"index" json:"orgId"`
+	TokenVersion int       `json:"-" gorm:"default:0"` // incrementado no logout para revogar tokens emitidos
 	CreatedAt    time.Time `json:"createdAt"`
 	UpdatedAt    time.Time `json:"updatedAt"`
 }
\ No newline at end of file
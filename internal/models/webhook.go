@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Status de entrega de webhook
+const (
+	WebhookPending = "pending"
+	WebhookSent    = "sent"
+	WebhookFailed  = "failed"
+)
+
+// Webhook é uma URL registrada por um usuário para receber eventos assinados (HMAC-SHA256).
+// Webhooks de admins recebem eventos de todos os usuários, como no /ws.
+type Webhook struct {
+	ID          uint     `gorm:"primaryKey" json:"id"`
+	UserID      uint     `gorm:"index" json:"userId"`
+	URL         string   `json:"url"`
+	Description string   `json:"description"`
+	Secret      string   `json:"-"`
+	Events      []string `gorm:"serializer:json" json:"events"` // vazio = todos
+	Active      bool     `gorm:"default:true" json:"active"`
+	// FailureCount conta tentativas falhas seguidas; ao atingir o limite o webhook é desativado
+	FailureCount int        `json:"failureCount"`
+	DisabledAt   *time.Time `json:"disabledAt,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+// WebhookDelivery é uma entrega de evento (e o log das tentativas), reprocessada com backoff.
+type WebhookDelivery struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	WebhookID      uint       `gorm:"index" json:"webhookId"`
+	EventType      string     `gorm:"type:varchar(64)" json:"eventType"`
+	Payload        string     `json:"payload"` // corpo JSON enviado (reaproveitado na reentrega)
+	Status         string     `gorm:"type:varchar(16);index:idx_webhook_due,priority:1" json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `gorm:"index:idx_webhook_due,priority:2" json:"nextAttemptAt"`
+	ResponseStatus int        `json:"responseStatus,omitempty"` // o corpo da resposta não é guardado
+	LastError      string     `json:"lastError,omitempty"`
+	RedeliveryOf   *uint      `json:"redeliveryOf,omitempty"`
+	DeliveredAt    *time.Time `json:"deliveredAt,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
@@ -0,0 +1,155 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// StatusCategory agrupa os status de qualquer workflow; o scheduler e os bloqueios só olham a categoria.
+type StatusCategory string
+
+const (
+	CategoryNotStarted StatusCategory = "not_started"
+	CategoryInProgress StatusCategory = "in_progress"
+	CategoryDone       StatusCategory = "done"
+)
+
+// MaxWorkflowStatuses limita o tamanho de um workflow.
+const MaxWorkflowStatuses = 30
+
+var statusKeyRe = regexp.MustCompile(`^[a-z0-9_-]{1,16}$`)
+
+// WorkflowStatus é um status do workflow; a ordem na lista é a ordem das colunas.
+type WorkflowStatus struct {
+	Key      TaskStatus     `json:"key"`
+	Name     string         `json:"name"`
+	Category StatusCategory `json:"category"`
+}
+
+// Workflow define os status de um projeto e as transições permitidas entre eles.
+type Workflow struct {
+	ID        uint             `gorm:"primaryKey" json:"id"`
+	ProjectID uint             `gorm:"uniqueIndex" json:"projectId"`
+	Statuses  []WorkflowStatus `gorm:"serializer:json" json:"statuses"`
+	// Transitions: status → próximos permitidos; nil libera qualquer transição
+	Transitions map[TaskStatus][]TaskStatus `gorm:"serializer:json" json:"transitions"`
+	CreatedAt   time.Time                   `json:"createdAt"`
+	UpdatedAt   time.Time                   `json:"updatedAt"`
+}
+
+// DefaultWorkflow é o de tarefas sem projeto e de projetos que não definiram o seu.
+func DefaultWorkflow() Workflow {
+	return Workflow{Statuses: []WorkflowStatus{
+		{Key: StatusTodo, Name: "A fazer", Category: CategoryNotStarted},
+		{Key: StatusDoing, Name: "Fazendo", Category: CategoryInProgress},
+		{Key: StatusDone, Name: "Feito", Category: CategoryDone},
+	}}
+}
+
+// Status devolve a definição do status key.
+func (w Workflow) Status(key TaskStatus) (WorkflowStatus, bool) {
+	for _, s := range w.Statuses {
+		if s.Key == key {
+			return s, true
+		}
+	}
+	return WorkflowStatus{}, false
+}
+
+// Category devolve a categoria do status (vazia se ele não existir no workflow).
+func (w Workflow) Category(key TaskStatus) StatusCategory {
+	s, _ := w.Status(key)
+	return s.Category
+}
+
+// Initial é o status de novas tarefas e de novas ocorrências: o primeiro da lista.
+func (w Workflow) Initial() TaskStatus {
+	if len(w.Statuses) == 0 {
+		return StatusTodo
+	}
+	return w.Statuses[0].Key
+}
+
+// Keys lista os status na ordem do workflow.
+func (w Workflow) Keys() []TaskStatus {
+	keys := make([]TaskStatus, len(w.Statuses))
+	for i, s := range w.Statuses {
+		keys[i] = s.Key
+	}
+	return keys
+}
+
+// Next lista os status para os quais uma tarefa em from pode ir.
+func (w Workflow) Next(from TaskStatus) []TaskStatus {
+	if w.Transitions == nil {
+		var next []TaskStatus
+		for _, k := range w.Keys() {
+			if k != from {
+				next = append(next, k)
+			}
+		}
+		return next
+	}
+	return append([]TaskStatus{}, w.Transitions[from]...)
+}
+
+// Allows informa se a transição from → to é permitida (ficar no mesmo status sempre é).
+func (w Workflow) Allows(from, to TaskStatus) bool {
+	if _, ok := w.Status(to); !ok {
+		return false
+	}
+	if from == to {
+		return true
+	}
+	for _, k := range w.Next(from) {
+		if k == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate confere a definição: chaves únicas, categorias conhecidas, ao menos um status
+// de conclusão e transições só entre status existentes.
+func (w Workflow) Validate() error {
+	if len(w.Statuses) == 0 || len(w.Statuses) > MaxWorkflowStatuses {
+		return fmt.Errorf("a workflow needs 1 to %d statuses", MaxWorkflowStatuses)
+	}
+	seen := map[TaskStatus]bool{}
+	done := false
+	for _, s := range w.Statuses {
+		if !statusKeyRe.MatchString(string(s.Key)) {
+			return fmt.Errorf("invalid status key %q (a-z, 0-9, _ or -, up to 16)", s.Key)
+		}
+		if seen[s.Key] {
+			return fmt.Errorf("duplicated status %q", s.Key)
+		}
+		seen[s.Key] = true
+		if s.Name == "" || len(s.Name) > 50 {
+			return fmt.Errorf("status %q needs a name up to 50 characters", s.Key)
+		}
+		switch s.Category {
+		case CategoryNotStarted, CategoryInProgress:
+		case CategoryDone:
+			done = true
+		default:
+			return fmt.Errorf("status %q: category must be not_started, in_progress or done", s.Key)
+		}
+	}
+	if !done {
+		return errors.New("a workflow needs at least one status in the done category")
+	}
+	for from, next := range w.Transitions {
+		if !seen[from] {
+			return fmt.Errorf("transition from unknown status %q", from)
+		}
+		for _, to := range next {
+			if !seen[to] {
+				return fmt.Errorf("transition to unknown status %q", to)
+			}
+		}
+	}
+	return nil
+}
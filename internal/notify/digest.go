@@ -0,0 +1,72 @@
+package notify
+
+import (
+	"time"
+
+	"goTasks/internal/mail"
+	"goTasks/internal/models"
+)
+
+// sendDigests enfileira o resumo matinal (atrasadas + vencem hoje) de quem optou por ele,
+// uma vez por dia local, a partir de DigestHour no fuso do usuário.
+func (s *Scheduler) sendDigests(now time.Time) error {
+	var prefs []models.NotificationPreference
+	if err := s.db.Where("digest_enabled = ?", true).Find(&prefs).Error; err != nil {
+		return err
+	}
+
+	for _, p := range prefs {
+		loc := p.Location()
+		local := now.In(loc)
+		today := local.Format("2006-01-02")
+		if local.Hour() < p.DigestHour || p.LastDigestDate == today {
+			continue
+		}
+		// marca o dia antes de montar o e-mail: outra execução concorrente perde a corrida
+		res := s.db.Model(&models.NotificationPreference{}).
+			Where("user_id = ? AND (last_digest_date IS NULL OR last_digest_date <> ?)", p.UserID, today).
+			Update("last_digest_date", today)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			continue
+		}
+
+		endOfDay := time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, loc)
+		var tasks []models.Task
+		if err := s.db.
+			Where("owner_id = ?", p.UserID).
+			Where("due_date IS NOT NULL AND due_date < ?", endOfDay).
+			Where("status_category <> ?", models.CategoryDone).
+			Order("due_date ASC").
+			Find(&tasks).Error; err != nil {
+			return err
+		}
+		if len(tasks) == 0 {
+			continue
+		}
+
+		var user models.User
+		if err := s.db.First(&user, "id = ?", p.UserID).Error; err != nil || user.Email == "" {
+			continue
+		}
+		d := mail.DigestEmail{UserName: user.Name, Date: local.Format("02/01/2006"), AppURL: s.notifier.appURL}
+		for _, t := range tasks {
+			item := mail.DigestTask{ID: t.ID, Title: t.Title, DueDate: t.DueDate.In(loc)}
+			if t.DueDate.Before(now) {
+				d.Overdue = append(d.Overdue, item)
+			} else {
+				d.DueToday = append(d.DueToday, item)
+			}
+		}
+		m, err := mail.RenderDigest(user.Email, d)
+		if err != nil {
+			return err
+		}
+		if err := s.notifier.mailer.Enqueue(user.ID, 0, "digest", m, now, "digest:"+today); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"goTasks/internal/models"
+	"goTasks/internal/policy"
+)
+
+// mentionRe captura "@ana" (parte local do e-mail) ou "@ana@empresa.com".
+var mentionRe = regexp.MustCompile(`(?:^|[^\w.])@([\w.+-]+(?:@[\w-]+(?:\.[\w-]+)+)?)`)
+
+// CommentCreated notifica quem acompanha a tarefa ("comment") e os @mencionados ("mention").
+func (s *Service) CommentCreated(task models.Task, comment models.Comment, author models.User) {
+	mentioned := s.visibleTo(task, s.mentionedUsers(comment.Content))
+	isMentioned := make(map[uint]bool, len(mentioned))
+	for _, id := range mentioned {
+		isMentioned[id] = true
+	}
+
+	base := Input{TaskID: task.ID, TaskTitle: task.Title, ActorID: author.ID}
+
+	in := base
+	in.Type = models.NotificationMention
+	in.Message = fmt.Sprintf("%s mencionou você em %s", author.Name, task.Title)
+	s.NotifyMany(mentioned, in)
+
+	var rest []uint
+	for _, id := range s.followers(task) {
+		if !isMentioned[id] {
+			rest = append(rest, id)
+		}
+	}
+	in = base
+	in.Type = models.NotificationComment
+	in.Message = fmt.Sprintf("%s comentou em %s", author.Name, task.Title)
+	s.NotifyMany(rest, in)
+}
+
+// StatusChanged avisa quem acompanha a tarefa sobre a mudança de status.
+func (s *Service) StatusChanged(task models.Task, from models.TaskStatus, actorID uint) {
+	s.NotifyMany(s.followers(task), Input{
+		TaskID:    task.ID,
+		TaskTitle: task.Title,
+		Type:      models.NotificationStatusChanged,
+		Message:   fmt.Sprintf("%s: %s → %s", task.Title, from, task.Status),
+		ActorID:   actorID,
+	})
+}
+
+// Reassigned avisa o novo dono e o anterior quando a tarefa muda de mãos.
+func (s *Service) Reassigned(task models.Task, previousOwner uint, actorID uint) {
+	base := Input{TaskID: task.ID, TaskTitle: task.Title, Type: models.NotificationAssigned, ActorID: actorID}
+
+	in := base
+	in.UserID = task.OwnerID
+	in.Message = "Você recebeu a tarefa: " + task.Title
+	if err := s.Notify(in); err != nil {
+		logNotifyErr(in, err)
+	}
+
+	in = base
+	in.UserID = previousOwner
+	in.Message = "A tarefa foi transferida: " + task.Title
+	if err := s.Notify(in); err != nil {
+		logNotifyErr(in, err)
+	}
+}
+
+// AssigneesChanged avisa quem passou a ser ou deixou de ser responsável pela tarefa.
+func (s *Service) AssigneesChanged(task models.Task, added, removed []uint, actorID uint) {
+	base := Input{TaskID: task.ID, TaskTitle: task.Title, Type: models.NotificationAssigned, ActorID: actorID}
+
+	in := base
+	in.Message = "Você é responsável pela tarefa: " + task.Title
+	s.NotifyMany(added, in)
+
+	in = base
+	in.Message = "Você não é mais responsável pela tarefa: " + task.Title
+	s.NotifyMany(removed, in)
+}
+
+// followers: dono, responsáveis, observadores e quem já comentou (se ainda tiver acesso).
+// Os demais membros do projeto recebem os eventos, mas não notificações.
+func (s *Service) followers(task models.Task) []uint {
+	ids := []uint{task.OwnerID}
+	if assignees, watchers, err := models.TaskMembers(s.db, task.ID); err == nil {
+		ids = append(append(ids, assignees...), watchers...)
+	}
+	var commenters []uint
+	if err := s.db.Model(&models.Comment{}).Where("task_id = ?", task.ID).Distinct().Pluck("user_id", &commenters).Error; err == nil {
+		ids = append(ids, commenters...)
+	}
+	return s.visibleTo(task, ids)
+}
+
+// visibleTo filtra os usuários que podem ver a tarefa.
+func (s *Service) visibleTo(task models.Task, ids []uint) []uint {
+	return policy.VisibleTo(s.db, task, ids)
+}
+
+// mentionedUsers resolve @menções pelo e-mail completo ou pela parte local.
+func (s *Service) mentionedUsers(content string) []uint {
+	var exact, local []string
+	for _, m := range mentionRe.FindAllStringSubmatch(content, -1) {
+		tok := strings.ToLower(strings.TrimRight(m[1], "."))
+		if strings.Contains(tok, "@") {
+			exact = append(exact, tok)
+		} else {
+			local = append(local, tok)
+		}
+	}
+	if len(exact) == 0 && len(local) == 0 {
+		return nil
+	}
+	q := s.db.Model(&models.User{})
+	cond := s.db.Where("1 = 0")
+	if len(exact) > 0 {
+		cond = cond.Or("LOWER(email) IN ?", exact)
+	}
+	for _, l := range local {
+		l = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(l)
+		cond = cond.Or(`LOWER(email) LIKE ? ESCAPE '\'`, l+"@%")
+	}
+	var ids []uint
+	if err := q.Where(cond).Pluck("id", &ids).Error; err != nil {
+		return nil
+	}
+	return ids
+}
+
+func logNotifyErr(in Input, err error) {
+	log.Printf("notify %s user %d err: %v", in.Type, in.UserID, err)
+}
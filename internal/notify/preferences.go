@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"goTasks/internal/models"
+)
+
+// LoadPreferences busca as preferências do usuário; sem registro, devolve o padrão.
+func LoadPreferences(db *gorm.DB, userID uint) (models.NotificationPreference, error) {
+	var p models.NotificationPreference
+	err := db.First(&p, "user_id = ?", userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.DefaultNotificationPreference(userID), nil
+	}
+	if err != nil {
+		return models.NotificationPreference{}, err
+	}
+	if p.Channels == nil {
+		p.Channels = models.DefaultNotificationPreference(userID).Channels
+	}
+	return p, nil
+}
+
+// LoadPreferencesFor carrega as preferências de vários usuários de uma vez.
+func LoadPreferencesFor(db *gorm.DB, userIDs []uint) (map[uint]models.NotificationPreference, error) {
+	out := make(map[uint]models.NotificationPreference, len(userIDs))
+	if len(userIDs) == 0 {
+		return out, nil
+	}
+	var rows []models.NotificationPreference
+	if err := db.Where("user_id IN ?", userIDs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, p := range rows {
+		if p.Channels == nil {
+			p.Channels = models.DefaultNotificationPreference(p.UserID).Channels
+		}
+		out[p.UserID] = p
+	}
+	for _, id := range userIDs {
+		if _, ok := out[id]; !ok {
+			out[id] = models.DefaultNotificationPreference(id)
+		}
+	}
+	return out, nil
+}
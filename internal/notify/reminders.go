@@ -0,0 +1,102 @@
+package notify
+
+import (
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"goTasks/internal/models"
+)
+
+// RescheduleReminders recalcula os lembretes relativos após mudar o vencimento;
+// os que mudaram de horário voltam a ficar pendentes.
+func RescheduleReminders(db *gorm.DB, taskID uint, due *time.Time) error {
+	var reminders []models.TaskReminder
+	if err := db.Where("task_id = ? AND offset_minutes IS NOT NULL", taskID).Find(&reminders).Error; err != nil {
+		return err
+	}
+	for _, r := range reminders {
+		if !r.Schedule(due) {
+			continue
+		}
+		if err := db.Model(&r).Updates(map[string]interface{}{"fire_at": r.FireAt, "fired_at": nil}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type dueReminder struct {
+	ID        uint
+	TaskID    uint
+	UserID    uint
+	FireAt    time.Time
+	Title     string
+	Status    models.TaskStatus
+	Category  models.StatusCategory `gorm:"column:status_category"`
+	OwnerID   uint
+	OrgID     uint
+	ProjectID *uint
+}
+
+// fireReminders dispara os lembretes vencidos. Cada um é reivindicado com um UPDATE
+// condicional, então dispara uma única vez mesmo com execuções concorrentes.
+func (s *Scheduler) fireReminders(now time.Time) error {
+	var lastID uint
+	for {
+		var batch []dueReminder
+		if err := s.db.Table("task_reminders AS r").
+			Select("r.id, r.task_id, r.user_id, r.fire_at, t.title, t.status, t.status_category, t.owner_id, t.org_id, t.project_id").
+			Joins("JOIN tasks t ON t.id = r.task_id AND t.deleted_at IS NULL").
+			Where("r.fired_at IS NULL AND r.fire_at <= ? AND r.id > ?", now, lastID).
+			Order("r.id ASC").Limit(scanBatchSize).
+			Scan(&batch).Error; err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		users := make([]uint, 0, len(batch))
+		for _, r := range batch {
+			users = append(users, r.UserID)
+		}
+		prefs, err := LoadPreferencesFor(s.db, users)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range batch {
+			lastID = r.ID
+			res := s.db.Model(&models.TaskReminder{}).
+				Where("id = ? AND fired_at IS NULL", r.ID).
+				Update("fired_at", now)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 || r.Category == models.CategoryDone {
+				continue
+			}
+			// quem criou pode ter perdido acesso (ex.: tarefa reatribuída)
+			task := models.Task{ID: r.TaskID, OwnerID: r.OwnerID, OrgID: r.OrgID, ProjectID: r.ProjectID}
+			if len(s.notifier.visibleTo(task, []uint{r.UserID})) == 0 {
+				continue
+			}
+			in := Input{
+				UserID:    r.UserID,
+				TaskID:    r.TaskID,
+				TaskTitle: r.Title,
+				Type:      models.NotificationReminder,
+				Message:   "Lembrete: " + r.Title,
+				DedupeKey: models.DueDedupeKey(models.NotificationReminder, r.TaskID, r.FireAt),
+			}
+			if err := s.notifier.deliver(prefs[r.UserID], in); err != nil {
+				log.Printf("notify reminder err: %v", err)
+			}
+		}
+		if len(batch) < scanBatchSize {
+			return nil
+		}
+	}
+}
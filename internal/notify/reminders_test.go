@@ -0,0 +1,60 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"goTasks/internal/models"
+)
+
+func TestRemindersFireOnceAndFollowDueDate(t *testing.T) {
+	svc, db := newTestService(t)
+	db.AutoMigrate(&models.TaskReminder{})
+	s := NewScheduler(db, svc)
+	owner := models.User{Name: "Ana", Email: "ana@example.com"}
+	db.Create(&owner)
+	now := time.Now()
+	due := now.Add(30 * time.Minute)
+	task := models.Task{Title: "Entrega", Status: models.StatusTodo, OwnerID: owner.ID, DueDate: &due}
+	db.Create(&task)
+
+	hour, week := 60, 7*24*60
+	at := now.Add(2 * time.Hour)
+	for _, r := range []models.TaskReminder{
+		{TaskID: task.ID, UserID: owner.ID, OffsetMinutes: &hour}, // já passou: dispara
+		{TaskID: task.ID, UserID: owner.ID, OffsetMinutes: &week}, // já passou: dispara
+		{TaskID: task.ID, UserID: owner.ID, At: &at},              // futuro
+	} {
+		r.Schedule(task.DueDate)
+		db.Create(&r)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := s.fireReminders(now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var fired int64
+	db.Model(&models.Notification{}).Where("type = ?", models.NotificationReminder).Count(&fired)
+	if fired != 2 {
+		t.Fatalf("expected 2 reminders fired once, got %d", fired)
+	}
+
+	// novo vencimento: os relativos voltam a ficar pendentes, o absoluto não muda
+	newDue := now.Add(10 * 24 * time.Hour)
+	if err := RescheduleReminders(db, task.ID, &newDue); err != nil {
+		t.Fatal(err)
+	}
+	var pending []models.TaskReminder
+	db.Where("fired_at IS NULL").Order("id").Find(&pending)
+	if len(pending) != 3 || !pending[0].FireAt.Equal(newDue.Add(-time.Hour)) || !pending[2].FireAt.Equal(at) {
+		t.Fatalf("unexpected reschedule: %+v", pending)
+	}
+	if err := s.fireReminders(now.Add(3 * time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	db.Model(&models.Notification{}).Where("type = ?", models.NotificationReminder).Count(&fired)
+	if fired != 3 {
+		t.Fatalf("expected absolute reminder to fire, got %d", fired)
+	}
+}
@@ -2,22 +2,59 @@ package notify
 
 import (
 	"log"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
+	"goTasks/internal/leader"
 	"goTasks/internal/models"
-	"goTasks/internal/ws"
+)
+
+// leaseTTL precisa cobrir alguns ticks: se o líder cair, outra réplica assume após esse tempo.
+const leaseTTL = 3 * time.Minute
+
+const (
+	dueWatermark  = "notify.due"
+	scanBatchSize = 500
+	// overdueRepeat: o aviso de atraso se repete a cada janela dessas enquanto a tarefa estiver aberta
+	overdueRepeat = 12 * time.Hour
 )
 
 type Scheduler struct {
-	db   *gorm.DB
-	hub  *ws.Hub
-	stop chan struct{}
+	db       *gorm.DB
+	notifier *Service
+	lease    *leader.Lease
+	stop     chan struct{}
+
+	mu     sync.Mutex
+	status Status
 }
 
-func NewScheduler(db *gorm.DB, hub *ws.Hub) *Scheduler {
-	return &Scheduler{db: db, hub: hub, stop: make(chan struct{})}
+// Status resume a saúde do scheduler nesta réplica.
+type Status struct {
+	Replica        string     `json:"replica"`
+	Leader         bool       `json:"leader"`
+	LastTickAt     *time.Time `json:"lastTickAt,omitempty"`
+	LastRunAt      *time.Time `json:"lastRunAt,omitempty"`
+	LastDurationMs int64      `json:"lastDurationMs"`
+	LastError      string     `json:"lastError,omitempty"`
+	Runs           int64      `json:"runs"`
+	Failures       int64      `json:"failures"`
+}
+
+func NewScheduler(db *gorm.DB, notifier *Service) *Scheduler {
+	lease := leader.NewLease(db, "notify.scheduler", leaseTTL)
+	return &Scheduler{
+		db:       db,
+		notifier: notifier,
+		lease:    lease,
+		stop:     make(chan struct{}),
+		status:   Status{Replica: lease.Holder()},
+	}
 }
 
 func (s *Scheduler) Start() {
@@ -27,9 +64,7 @@ func (s *Scheduler) Start() {
 		for {
 			select {
 			case <-ticker.C:
-				if err := s.runOnce(); err != nil {
-					log.Printf("scheduler run error: %v", err)
-				}
+				s.tick()
 			case <-s.stop:
 				return
 			}
@@ -39,69 +74,288 @@ func (s *Scheduler) Start() {
 
 func (s *Scheduler) Stop() {
 	close(s.stop)
+	if err := s.lease.Release(); err != nil {
+		log.Printf("scheduler lease release error: %v", err)
+	}
+}
+
+// Status devolve uma cópia do estado desta réplica.
+func (s *Scheduler) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// Lease devolve o arrendamento no banco (quem lidera e como foi a última execução).
+func (s *Scheduler) Lease() (models.Lease, error) {
+	return s.lease.Current()
+}
+
+// tick roda uma varredura só se esta réplica for a líder.
+func (s *Scheduler) tick() {
+	now := time.Now()
+	ok, err := s.lease.TryAcquire()
+	s.mu.Lock()
+	s.status.LastTickAt = &now
+	s.status.Leader = ok && err == nil
+	s.mu.Unlock()
+	if err != nil {
+		log.Printf("scheduler lease error: %v", err)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	runErr := s.runOnce()
+	took := time.Since(now)
+	if runErr != nil {
+		log.Printf("scheduler run error: %v", runErr)
+	}
+	if err := s.lease.RecordRun(now, runErr); err != nil {
+		log.Printf("scheduler lease record error: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.Runs++
+	s.status.LastRunAt = &now
+	s.status.LastDurationMs = took.Milliseconds()
+	s.status.LastError = ""
+	if runErr != nil {
+		s.status.Failures++
+		s.status.LastError = runErr.Error()
+	}
 }
 
 func (s *Scheduler) runOnce() error {
 	now := time.Now()
-	soon := now.Add(24 * time.Hour)
-
-	// Seleciona tarefas com dueDate definido e não concluídas
-	var tasks []models.Task
-	if err := s.db.
-		Where("due_date IS NOT NULL").
-		Where("status <> ?", models.StatusDone).
-		Find(&tasks).Error; err != nil {
+	if err := s.scanDue(now); err != nil {
+		return err
+	}
+	if err := s.fireReminders(now); err != nil {
 		return err
 	}
 
-	for _, t := range tasks {
-		if t.DueDate == nil {
-			continue
-		}
-		d := *t.DueDate
-		// Overdue
-		if d.Before(now) {
-			if err := s.createUniqueNotification(t.OwnerID, t.ID, "overdue", "Tarefa atrasada: "+t.Title, 12*time.Hour); err != nil {
-				log.Printf("notify overdue err: %v", err)
-			}
-			continue
-		}
-		// Due soon (próximas 24h)
-		if d.After(now) && d.Before(soon) {
-			if err := s.createUniqueNotification(t.OwnerID, t.ID, "due_soon", "Tarefa vence em breve: "+t.Title, 12*time.Hour); err != nil {
-				log.Printf("notify due_soon err: %v", err)
-			}
+	if s.notifier.mailer != nil {
+		if err := s.sendDigests(now); err != nil {
+			log.Printf("digest err: %v", err)
 		}
 	}
 	return nil
 }
 
-// Cria notificação evitando duplicatas recentes (janela de dedupe)
-func (s *Scheduler) createUniqueNotification(userID, taskID uint, typ, msg string, dedupeWindow time.Duration) error {
-	// Verifica se já existe notificação não lida igual nas últimas X horas
-	var count int64
-	cut := time.Now().Add(-dedupeWindow)
-	if err := s.db.Model(&models.Notification{}).
-		Where("user_id = ? AND task_id = ? AND type = ? AND created_at >= ?", userID, taskID, typ, cut).
-		Count(&count).Error; err != nil {
+// scanDue processa só as janelas de lembrete (vencimento ou antecedência) que abriram desde a
+// última execução, mais as tarefas editadas nesse intervalo, em lotes por (due_date, id).
+// Ao virar a janela de repetição do atraso, todas as tarefas atrasadas entram de novo.
+// A marca d'água só avança no fim: se a execução falhar, a próxima refaz o trecho e o
+// dedupe no banco (type:task:due-unix, ou type:task:janela no atraso) impede repetições.
+func (s *Scheduler) scanDue(now time.Time) error {
+	since, err := s.watermark()
+	if err != nil {
+		return err
+	}
+	leads, err := s.leadTimes()
+	if err != nil {
 		return err
 	}
-	if count > 0 {
-		return nil
+	// uma faixa de vencimento por antecedência: (since+lead, now+lead]; lead 0 = atraso.
+	// Faixas que se tocam viram uma só, para a consulta não crescer com antecedências vizinhas.
+	var (
+		arms []string
+		args []interface{}
+	)
+	for _, w := range dueWindows(since, now, leads) {
+		arms = append(arms, "(due_date > ? AND due_date <= ?)")
+		args = append(args, w[0], w[1])
 	}
+	arms = append(arms, "updated_at > ?")
+	args = append(args, since)
+	if overdueRepeats(since, now) {
+		arms = append(arms, "due_date <= ?")
+		args = append(args, now)
+	}
+	windows := strings.Join(arms, " OR ")
+	longest := leads[len(leads)-1]
+
+	var (
+		lastDue time.Time
+		lastID  uint
+	)
+	for {
+		q := s.db.
+			Select("id", "title", "owner_id", "org_id", "due_date", "status", "updated_at").
+			Where("due_date IS NOT NULL AND due_date <= ?", now.Add(longest)).
+			Where("status_category <> ?", models.CategoryDone).
+			Where(windows, args...)
+		if lastID != 0 {
+			q = q.Where("due_date > ? OR (due_date = ? AND id > ?)", lastDue, lastDue, lastID)
+		}
+		var batch []models.Task
+		if err := q.Order("due_date ASC, id ASC").Limit(scanBatchSize).Find(&batch).Error; err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		if err := s.processDue(batch, since, now); err != nil {
+			return err
+		}
+		last := batch[len(batch)-1]
+		lastDue, lastID = *last.DueDate, last.ID
+		if len(batch) < scanBatchSize {
+			break
+		}
+	}
+	return s.setWatermark(now)
+}
 
-	n := models.Notification{
-		UserID:  userID,
-		TaskID:  taskID,
-		Type:    typ,
-		Message: msg,
-		Read:    false,
+func (s *Scheduler) processDue(batch []models.Task, since, now time.Time) error {
+	ids := make([]uint, 0, len(batch))
+	for _, t := range batch {
+		ids = append(ids, t.ID)
 	}
-	if err := s.db.Create(&n).Error; err != nil {
+	assignees, err := models.AssigneesByTask(s.db, ids)
+	if err != nil {
+		return err
+	}
+	var candidates []uint
+	for _, t := range batch {
+		candidates = append(append(candidates, t.OwnerID), assignees[t.ID]...)
+	}
+	orgs, err := userOrgs(s.db, candidates)
+	if err != nil {
+		return err
+	}
+	// dono e responsáveis de cada tarefa recebem os avisos de vencimento, só dentro da organização dela
+	recipients := make(map[uint][]uint, len(batch))
+	var users []uint
+	for _, t := range batch {
+		var r []uint
+		for _, uid := range append([]uint{t.OwnerID}, assignees[t.ID]...) {
+			if org, ok := orgs[uid]; ok && org == t.OrgID && !containsUint(r, uid) {
+				r = append(r, uid)
+			}
+		}
+		recipients[t.ID] = r
+		users = append(users, r...)
+	}
+	prefs, err := LoadPreferencesFor(s.db, users)
+	if err != nil {
 		return err
 	}
 
-	// Broadcast WS
-	s.hub.Broadcast(ws.Event{Type: "notification.created", Payload: n})
+	for _, t := range batch {
+		for _, uid := range recipients[t.ID] {
+			s.notifyDue(t, prefs[uid], since, now)
+		}
+	}
 	return nil
+}
+
+// userOrgs devolve a organização de cada usuário.
+func userOrgs(db *gorm.DB, ids []uint) (map[uint]uint, error) {
+	var rows []models.User
+	if len(ids) > 0 {
+		if err := db.Select("id", "org_id").Where("id IN ?", ids).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+	}
+	out := make(map[uint]uint, len(rows))
+	for _, u := range rows {
+		out[u.ID] = u.OrgID
+	}
+	return out, nil
+}
+
+func containsUint(ids []uint, id uint) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// notifyDue avalia as janelas de atraso e de vencimento próximo da tarefa para um destinatário.
+func (s *Scheduler) notifyDue(t models.Task, p models.NotificationPreference, since, now time.Time) {
+	d := *t.DueDate
+	// tarefa editada (ex.: nova data no passado) é reavaliada mesmo com a janela já aberta
+	touched := t.UpdatedAt.After(since)
+	in := Input{UserID: p.UserID, TaskID: t.ID, TaskTitle: t.Title}
+	// Overdue
+	if d.Before(now) {
+		if !d.After(since) && !touched && !overdueRepeats(since, now) {
+			return
+		}
+		// um aviso por janela de 12h enquanto estiver atrasada
+		in.Type, in.Message = models.NotificationOverdue, "Tarefa atrasada: "+t.Title
+		in.DedupeKey = models.WindowDedupeKey(in.Type, t.ID, now, overdueRepeat)
+		if err := s.notifier.deliver(p, in); err != nil {
+			log.Printf("notify overdue err: %v", err)
+		}
+		return
+	}
+	// Due soon (janela aberta pela antecedência escolhida pelo usuário)
+	opens := d.Add(-p.DueSoonLead())
+	if opens.After(now) || (!opens.After(since) && !touched) {
+		return
+	}
+	in.Type, in.Message = models.NotificationDueSoon, "Tarefa vence em breve: "+t.Title
+	in.DedupeKey = models.DueDedupeKey(in.Type, t.ID, d)
+	if err := s.notifier.deliver(p, in); err != nil {
+		log.Printf("notify due_soon err: %v", err)
+	}
+}
+
+// overdueRepeats diz se (since, now] vira uma janela de repetição do atraso (as mesmas de WindowDedupeKey).
+func overdueRepeats(since, now time.Time) bool {
+	w := int64(overdueRepeat / time.Second)
+	return !since.IsZero() && since.Unix()/w != now.Unix()/w
+}
+
+// leadTimes devolve as antecedências de due-soon em uso, em ordem crescente; sempre inclui
+// o padrão (quem não configurou) e o 0, que é a janela de atraso.
+func (s *Scheduler) leadTimes() ([]time.Duration, error) {
+	var minutes []int
+	if err := s.db.Model(&models.NotificationPreference{}).
+		Distinct("due_soon_lead_minutes").Where("due_soon_lead_minutes > 0").
+		Pluck("due_soon_lead_minutes", &minutes).Error; err != nil {
+		return nil, err
+	}
+	leads := []time.Duration{0, models.DefaultDueSoonLead}
+	for _, m := range minutes {
+		if d := time.Duration(m) * time.Minute; d != models.DefaultDueSoonLead {
+			leads = append(leads, d)
+		}
+	}
+	sort.Slice(leads, func(i, j int) bool { return leads[i] < leads[j] })
+	return leads, nil
+}
+
+// dueWindows devolve as faixas de vencimento (since+lead, now+lead] de cada antecedência
+// (em ordem crescente), unindo as que se sobrepõem ou se tocam.
+func dueWindows(since, now time.Time, leads []time.Duration) [][2]time.Time {
+	var out [][2]time.Time
+	for _, lead := range leads {
+		w := [2]time.Time{since.Add(lead), now.Add(lead)}
+		if n := len(out); n > 0 && !w[0].After(out[n-1][1]) {
+			out[n-1][1] = w[1]
+			continue
+		}
+		out = append(out, w)
+	}
+	return out
+}
+
+// watermark devolve até quando a varredura já rodou (zero na primeira vez).
+func (s *Scheduler) watermark() (time.Time, error) {
+	var st models.SchedulerState
+	err := s.db.Where("name = ?", dueWatermark).Limit(1).Find(&st).Error
+	return st.Watermark, err
+}
+
+func (s *Scheduler) setWatermark(at time.Time) error {
+	return s.db.Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(&models.SchedulerState{Name: dueWatermark, Watermark: at}).Error
 }
\ No newline at end of file
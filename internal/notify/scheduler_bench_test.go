@@ -0,0 +1,136 @@
+package notify
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"goTasks/internal/models"
+)
+
+// legacyScan reproduz a varredura anterior à marca d'água, só para comparação: carrega
+// toda tarefa aberta dentro do horizonte e faz count-then-insert por tarefa, a cada minuto.
+func legacyScan(db *gorm.DB, now time.Time) error {
+	var tasks []models.Task
+	if err := db.
+		Where("due_date IS NOT NULL").
+		Where("due_date <= ?", now.Add(models.DefaultDueSoonLead)).
+		Where("status <> ?", models.StatusDone).
+		Find(&tasks).Error; err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		typ := models.NotificationDueSoon
+		if t.DueDate.Before(now) {
+			typ = models.NotificationOverdue
+		}
+		var count int64
+		if err := db.Model(&models.Notification{}).
+			Where("user_id = ? AND task_id = ? AND type = ? AND created_at >= ?", t.OwnerID, t.ID, typ, now.Add(-12*time.Hour)).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			n := models.Notification{UserID: t.OwnerID, TaskID: t.ID, Type: typ, Message: t.Title}
+			if err := db.Create(&n).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// seedDue cria n tarefas com vencimentos espalhados em ±30 dias entre 50 donos.
+func seedDue(b *testing.B, db *gorm.DB, n int, now time.Time) {
+	b.Helper()
+	users := make([]models.User, 50)
+	for i := range users {
+		users[i] = models.User{Name: fmt.Sprintf("u%d", i), Email: fmt.Sprintf("u%d@example.com", i)}
+	}
+	if err := db.Create(&users).Error; err != nil {
+		b.Fatal(err)
+	}
+	tasks := make([]models.Task, n)
+	span := 60 * 24 * time.Hour
+	for i := range tasks {
+		d := now.Add(-span/2 + span/time.Duration(n)*time.Duration(i)) // i*span estoura o int64
+		// editadas antes da primeira varredura, senão o arm de updated_at pega todas no primeiro tick medido
+		tasks[i] = models.Task{Title: fmt.Sprintf("t%d", i), Status: models.StatusTodo, OwnerID: users[i%len(users)].ID, DueDate: &d,
+			CreatedAt: now.Add(-time.Hour), UpdatedAt: now.Add(-time.Hour)}
+	}
+	if err := db.CreateInBatches(&tasks, 500).Error; err != nil {
+		b.Fatal(err)
+	}
+}
+
+const benchTasks = 5000
+
+func BenchmarkScanLegacy(b *testing.B) {
+	_, db := newTestService(b)
+	now := time.Now()
+	seedDue(b, db, benchTasks, now)
+	if err := legacyScan(db, now); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := legacyScan(db, now.Add(time.Duration(i+1)*time.Minute)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkScanIncremental(b *testing.B) {
+	svc, db := newTestService(b)
+	s := NewScheduler(db, svc)
+	now := time.Now()
+	seedDue(b, db, benchTasks, now)
+	// primeira execução (sem marca d'água) cobre o histórico inteiro
+	if err := s.scanDue(now); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := s.scanDue(now.Add(time.Duration(i+1) * time.Minute)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkScanIncrementalMixedLeads: antecedências diferentes entre os usuários não podem
+// transformar a varredura incremental numa varredura da janela mais longa a cada tick.
+func BenchmarkScanIncrementalMixedLeads(b *testing.B) {
+	svc, db := newTestService(b)
+	s := NewScheduler(db, svc)
+	now := time.Now()
+	seedDue(b, db, benchTasks, now)
+	var users []models.User
+	db.Find(&users)
+	leads := []int{15, 60, 24 * 60, 7 * 24 * 60}
+	for i, u := range users {
+		p := models.DefaultNotificationPreference(u.ID)
+		p.DueSoonLeadMinutes = leads[i%len(leads)]
+		if err := db.Create(&p).Error; err != nil {
+			b.Fatal(err)
+		}
+	}
+	if err := s.scanDue(now); err != nil {
+		b.Fatal(err)
+	}
+	// tarefas lidas por tick: o tempo é dominado pela varredura da tabela no SQLite, a regressão aparece aqui
+	var scanned int64
+	db.Callback().Query().After("gorm:query").Register("bench:scanned", func(tx *gorm.DB) {
+		if tx.Statement.Table == "tasks" {
+			scanned += tx.Statement.RowsAffected
+		}
+	})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := s.scanDue(now.Add(time.Duration(i+1) * time.Minute)); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(scanned)/float64(b.N), "tasks/op")
+}
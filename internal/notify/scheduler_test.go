@@ -0,0 +1,163 @@
+package notify
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"goTasks/internal/models"
+)
+
+func TestScanDueNotifiesEachWindowOnce(t *testing.T) {
+	svc, db := newTestService(t)
+	s := NewScheduler(db, svc)
+	owner := models.User{Name: "Ana", Email: "ana@example.com"}
+	db.Create(&owner)
+
+	base := time.Now().Add(-10 * time.Minute)
+	due := func(d time.Duration) *time.Time { v := base.Add(d); return &v }
+	overdue := models.Task{Title: "Atrasada", Status: models.StatusTodo, OwnerID: owner.ID, DueDate: due(-2 * time.Hour)}
+	soon := models.Task{Title: "Logo", Status: models.StatusDoing, OwnerID: owner.ID, DueDate: due(2 * time.Hour)}
+	later := models.Task{Title: "Depois", Status: models.StatusTodo, OwnerID: owner.ID, DueDate: due(72 * time.Hour)}
+	done := models.Task{Title: "Feita", Status: models.StatusDone, OwnerID: owner.ID, DueDate: due(-time.Hour)}
+	for _, task := range []*models.Task{&overdue, &soon, &later, &done} {
+		db.Create(task)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := s.scanDue(base.Add(time.Duration(i) * time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	counts := func() map[string]int64 {
+		out := map[string]int64{}
+		var rows []models.Notification
+		db.Find(&rows)
+		seen := map[string]bool{}
+		for _, n := range rows {
+			// o atraso se repete a cada 12h: conta uma vez por tarefa, caso o teste cruze uma janela
+			if k := fmt.Sprint(n.Type, n.TaskID); !seen[k] {
+				seen[k] = true
+				out[n.Type]++
+			}
+			if n.TaskID == done.ID || (n.TaskID == later.ID && n.Type == models.NotificationDueSoon) {
+				t.Fatalf("unexpected notification %+v", n)
+			}
+		}
+		return out
+	}
+	if c := counts(); c[models.NotificationOverdue] != 1 || c[models.NotificationDueSoon] != 1 {
+		t.Fatalf("expected one overdue and one due_soon, got %v", c)
+	}
+	mark, err := s.watermark()
+	if err != nil || !mark.Equal(base.Add(2*time.Minute)) {
+		t.Fatalf("watermark not persisted: %v %v", mark, err)
+	}
+
+	// nova data já vencida: a janela abriu antes da marca, mas a edição reabre o lembrete
+	db.Model(&later).Update("due_date", base.Add(-time.Hour))
+	if err := s.scanDue(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if c := counts(); c[models.NotificationOverdue] != 2 {
+		t.Fatalf("expected edited task to become overdue, got %v", c)
+	}
+}
+
+func TestScanDueStaysInsideTheOrganization(t *testing.T) {
+	svc, db := newTestService(t)
+	s := NewScheduler(db, svc)
+	ana := models.User{Name: "Ana", Email: "ana@example.com", OrgID: 1}
+	bia := models.User{Name: "Bia", Email: "bia@example.com", OrgID: 1}
+	zed := models.User{Name: "Zed", Email: "zed@example.com", OrgID: 2}
+	for _, u := range []*models.User{&ana, &bia, &zed} {
+		db.Create(u)
+	}
+	due := time.Now().Add(-time.Hour)
+	task := models.Task{Title: "Atrasada", Status: models.StatusTodo, OwnerID: ana.ID, OrgID: 1, DueDate: &due}
+	db.Create(&task)
+	// vínculo inválido (ex.: dado antigo): o responsável de outra organização não pode ser avisado
+	for _, uid := range []uint{bia.ID, zed.ID} {
+		db.Exec("INSERT INTO "+models.TaskAssigneesTable+" (task_id, user_id) VALUES (?, ?)", task.ID, uid)
+	}
+
+	if err := s.scanDue(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	var got []uint
+	db.Model(&models.Notification{}).Where("type = ?", models.NotificationOverdue).Order("user_id").Pluck("user_id", &got)
+	if len(got) != 2 || got[0] != ana.ID || got[1] != bia.ID {
+		t.Fatalf("expected overdue only for ana and bia, got %v", got)
+	}
+}
+
+func TestScanDueWithMixedLeadTimes(t *testing.T) {
+	svc, db := newTestService(t)
+	s := NewScheduler(db, svc)
+	ana := models.User{Name: "Ana", Email: "ana@example.com"}
+	bia := models.User{Name: "Bia", Email: "bia@example.com"}
+	db.Create(&ana)
+	db.Create(&bia)
+	for user, minutes := range map[uint]int{ana.ID: 30, bia.ID: 7 * 24 * 60} {
+		p := models.DefaultNotificationPreference(user)
+		p.DueSoonLeadMinutes = minutes
+		db.Create(&p)
+	}
+	base := time.Now()
+	at := func(d time.Duration) *time.Time { v := base.Add(d); return &v }
+	short := models.Task{Title: "Curta", Status: models.StatusTodo, OwnerID: ana.ID, DueDate: at(45 * time.Minute)}
+	long := models.Task{Title: "Longa", Status: models.StatusTodo, OwnerID: bia.ID, DueDate: at(3 * 24 * time.Hour)}
+	db.Create(&short)
+	db.Create(&long)
+
+	dueSoon := func(task models.Task) int64 {
+		var n int64
+		db.Model(&models.Notification{}).Where("task_id = ? AND type = ?", task.ID, models.NotificationDueSoon).Count(&n)
+		return n
+	}
+	if err := s.scanDue(base); err != nil {
+		t.Fatal(err)
+	}
+	if dueSoon(long) != 1 || dueSoon(short) != 0 {
+		t.Fatalf("only the 7-day window is open: long=%d short=%d", dueSoon(long), dueSoon(short))
+	}
+	// a janela de 30 min da Ana abre entre dois ticks
+	for i := 1; i <= 20; i++ {
+		if err := s.scanDue(base.Add(time.Duration(i) * time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if dueSoon(long) != 1 || dueSoon(short) != 1 {
+		t.Fatalf("each window must be notified once: long=%d short=%d", dueSoon(long), dueSoon(short))
+	}
+
+	// faixas por antecedência: só o que abriu no intervalo, unindo as vizinhas
+	now := base.Add(time.Minute)
+	w := dueWindows(base, now, []time.Duration{0, 30 * time.Minute, 31 * time.Minute, 24 * time.Hour})
+	if len(w) != 3 || !w[1][0].Equal(base.Add(30*time.Minute)) || !w[1][1].Equal(now.Add(31*time.Minute)) || !w[2][1].Equal(now.Add(24*time.Hour)) {
+		t.Fatalf("unexpected windows: %v", w)
+	}
+}
+
+func TestOverdueRepeatsEveryWindow(t *testing.T) {
+	svc, db := newTestService(t)
+	s := NewScheduler(db, svc)
+	owner := models.User{Name: "Ana", Email: "ana@example.com"}
+	db.Create(&owner)
+	due := time.Now().Add(-30 * time.Hour)
+	task := models.Task{Title: "Atrasada", Status: models.StatusTodo, OwnerID: owner.ID, DueDate: &due}
+	db.Create(&task)
+
+	now := time.Now()
+	next := now.Truncate(overdueRepeat).Add(overdueRepeat) // próxima virada de janela
+	for _, at := range []time.Time{now, now.Add(time.Second), next.Add(time.Minute), next.Add(2 * time.Minute)} {
+		if err := s.scanDue(at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var n int64
+	db.Model(&models.Notification{}).Where("task_id = ? AND type = ?", task.ID, models.NotificationOverdue).Count(&n)
+	if n != 2 {
+		t.Fatalf("overdue must repeat once per 12h window, got %d", n)
+	}
+}
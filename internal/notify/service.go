@@ -0,0 +1,222 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"goTasks/internal/mail"
+	"goTasks/internal/models"
+	"goTasks/internal/ws"
+)
+
+// Service é o ponto único de produção de notificações: aplica preferências,
+// dedupe e agrupamento, grava o in-app, publica no hub e enfileira e-mails.
+type Service struct {
+	db     *gorm.DB
+	hub    *ws.Hub
+	mailer *mail.Queue // nil = canal de e-mail desativado
+	appURL string
+}
+
+func NewService(db *gorm.DB, hub *ws.Hub, mailer *mail.Queue, appURL string) *Service {
+	return &Service{db: db, hub: hub, mailer: mailer, appURL: appURL}
+}
+
+// Input descreve uma notificação a produzir.
+type Input struct {
+	UserID    uint
+	TaskID    uint
+	TaskTitle string
+	Type      string
+	Message   string
+	ActorID   uint // quem causou; nunca é notificado do próprio ato
+	// DedupeKey, se definida, substitui a regra do tipo: no máximo uma notificação (e um e-mail) por chave
+	DedupeKey string
+}
+
+// rule define como cada tipo é deduplicado/agrupado.
+type rule struct {
+	dedupe time.Duration // não repete notificação igual dentro da janela (0 = sem dedupe)
+	// aggregate != nil: eventos novos somam na notificação não lida existente
+	aggregate func(count int, title, latest string) string
+}
+
+var rules = map[string]rule{
+	models.NotificationOverdue: {dedupe: 12 * time.Hour},
+	models.NotificationDueSoon: {dedupe: 12 * time.Hour},
+	models.NotificationComment: {aggregate: func(n int, title, _ string) string {
+		return fmt.Sprintf("%d novos comentários em %s", n, title)
+	}},
+	models.NotificationMention: {aggregate: func(n int, title, _ string) string {
+		return fmt.Sprintf("Você foi mencionado %d vezes em %s", n, title)
+	}},
+	models.NotificationStatusChanged: {aggregate: func(_ int, _, latest string) string {
+		return latest
+	}},
+	models.NotificationAssigned: {dedupe: time.Minute},
+}
+
+// emailWindow evita um e-mail por evento em tipos agrupados.
+const emailWindow = time.Hour
+
+// Notify produz uma notificação para um usuário.
+func (s *Service) Notify(in Input) error {
+	if in.UserID == 0 || in.UserID == in.ActorID {
+		return nil
+	}
+	p, err := LoadPreferences(s.db, in.UserID)
+	if err != nil {
+		return err
+	}
+	return s.deliver(p, in)
+}
+
+// NotifyMany produz a mesma notificação para vários usuários (sem repetir).
+func (s *Service) NotifyMany(userIDs []uint, in Input) {
+	seen := make(map[uint]bool, len(userIDs))
+	for _, uid := range userIDs {
+		if seen[uid] {
+			continue
+		}
+		seen[uid] = true
+		in.UserID = uid
+		if err := s.Notify(in); err != nil {
+			log.Printf("notify %s user %d err: %v", in.Type, uid, err)
+		}
+	}
+}
+
+func (s *Service) deliver(p models.NotificationPreference, in Input) error {
+	r := rules[in.Type]
+	now := time.Now()
+
+	if p.Wants(in.Type, models.ChannelInApp) {
+		if err := s.deliverInApp(p, in, r, now); err != nil {
+			return err
+		}
+	}
+
+	if s.mailer != nil && p.Wants(in.Type, models.ChannelEmail) {
+		window := r.dedupe
+		if r.aggregate != nil {
+			window = emailWindow
+		}
+		key := in.DedupeKey
+		if key == "" && window > 0 {
+			sent, err := s.mailer.Sent(in.UserID, in.TaskID, in.Type, now.Add(-window))
+			if err != nil || sent {
+				return err
+			}
+			key = models.WindowDedupeKey(in.Type, in.TaskID, now, window)
+		}
+		var user models.User
+		if err := s.db.Select("id", "name", "email").First(&user, "id = ?", in.UserID).Error; err != nil || user.Email == "" {
+			return err
+		}
+		m, err := mail.RenderNotification(user.Email, mail.NotificationEmail{
+			UserName: user.Name, Message: in.Message, TaskID: in.TaskID, TaskTitle: in.TaskTitle, AppURL: s.appURL,
+		})
+		if err != nil {
+			return err
+		}
+		// no horário de silêncio o e-mail espera até o fim da janela
+		return s.mailer.Enqueue(in.UserID, in.TaskID, in.Type, m, p.QuietHoursEndAfter(now), key)
+	}
+	return nil
+}
+
+func (s *Service) deliverInApp(p models.NotificationPreference, in Input, r rule, now time.Time) error {
+	var actor *uint
+	if in.ActorID != 0 {
+		a := in.ActorID
+		actor = &a
+	}
+
+	var key *string
+	if in.DedupeKey != "" {
+		k := in.DedupeKey
+		key = &k
+	} else if r.aggregate != nil {
+		if ok, err := s.aggregateInto(p, in, r, actor, now); ok || err != nil {
+			return err
+		}
+		k := models.UnreadDedupeKey(in.Type, in.TaskID)
+		key = &k
+	} else if r.dedupe > 0 {
+		// Verifica se já existe notificação igual dentro da janela
+		var count int64
+		if err := s.db.Model(&models.Notification{}).
+			Where("user_id = ? AND task_id = ? AND type = ? AND created_at >= ?", in.UserID, in.TaskID, in.Type, now.Add(-r.dedupe)).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		k := models.WindowDedupeKey(in.Type, in.TaskID, now, r.dedupe)
+		key = &k
+	}
+
+	n := models.Notification{
+		UserID:    in.UserID,
+		TaskID:    in.TaskID,
+		Type:      in.Type,
+		Message:   in.Message,
+		Count:     1,
+		ActorID:   actor,
+		DedupeKey: key,
+	}
+	// a chave única resolve corridas entre réplicas: quem chega depois não insere
+	res := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&n)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		if r.aggregate != nil && in.DedupeKey == "" {
+			_, err := s.aggregateInto(p, in, r, actor, now)
+			return err
+		}
+		return nil
+	}
+	s.publish(p, "notification.created", n, now)
+	return nil
+}
+
+// aggregateInto soma o evento à notificação não lida existente; false se não houver uma.
+func (s *Service) aggregateInto(p models.NotificationPreference, in Input, r rule, actor *uint, now time.Time) (bool, error) {
+	var existing models.Notification
+	err := s.db.Where("user_id = ? AND task_id = ? AND type = ? AND read = ?", in.UserID, in.TaskID, in.Type, false).
+		Order("id DESC").First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	// incremento no próprio UPDATE para não perder eventos concorrentes
+	if err := s.db.Model(&existing).Updates(map[string]interface{}{
+		"count": gorm.Expr("count + 1"), "actor_id": actor,
+	}).Error; err != nil {
+		return false, err
+	}
+	if err := s.db.First(&existing, existing.ID).Error; err != nil {
+		return false, err
+	}
+	existing.Message = r.aggregate(existing.Count, in.TaskTitle, in.Message)
+	if err := s.db.Model(&existing).Update("message", existing.Message).Error; err != nil {
+		return false, err
+	}
+	s.publish(p, "notification.updated", existing, now)
+	return true, nil
+}
+
+// publish envia ao hub. No horário de silêncio o evento só chega aos listeners (webhooks,
+// chat, que aplica o silêncio por conta própria): o usuário não recebe o push e vê a notificação no sino.
+func (s *Service) publish(p models.NotificationPreference, typ string, n models.Notification, now time.Time) {
+	s.hub.Broadcast(ws.Event{Type: typ, Payload: n, To: []uint{n.UserID}, Silent: p.InQuietHours(now)})
+}
@@ -0,0 +1,133 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"goTasks/internal/models"
+	"goTasks/internal/ws"
+)
+
+func newTestService(t testing.TB) (*Service, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&models.User{}, &models.Task{}, &models.Comment{}, &models.Notification{}, &models.NotificationPreference{}, &models.Lease{}, &models.SchedulerState{}); err != nil {
+		t.Fatal(err)
+	}
+	hub := ws.NewHub()
+	go hub.Run()
+	return NewService(db, hub, nil, "http://app"), db
+}
+
+func TestCommentsAreAggregatedAndMentionsResolved(t *testing.T) {
+	svc, db := newTestService(t)
+	owner := models.User{Name: "Ana", Email: "ana@example.com", Role: "user"}
+	bob := models.User{Name: "Bob", Email: "bob@example.com", Role: "user"}
+	admin := models.User{Name: "Root", Email: "root@example.com", Role: "admin"}
+	db.Create(&owner)
+	db.Create(&bob)
+	db.Create(&admin)
+	task := models.Task{Title: "Relatório", Status: models.StatusTodo, OwnerID: owner.ID}
+	db.Create(&task)
+
+	for i := 0; i < 3; i++ {
+		c := models.Comment{TaskID: task.ID, UserID: admin.ID, Content: "ok"}
+		db.Create(&c)
+		svc.CommentCreated(task, c, admin)
+	}
+	var ns []models.Notification
+	db.Where("user_id = ?", owner.ID).Find(&ns)
+	if len(ns) != 1 || ns[0].Count != 3 || ns[0].Message != "3 novos comentários em Relatório" {
+		t.Fatalf("expected one aggregated notification, got %+v", ns)
+	}
+	var self int64
+	db.Model(&models.Notification{}).Where("user_id = ?", admin.ID).Count(&self)
+	if self != 0 {
+		t.Fatalf("author must not be notified of own comments")
+	}
+
+	// bob não vê a tarefa: a menção não pode vazar; a do admin vira "mention"
+	c := models.Comment{TaskID: task.ID, UserID: owner.ID, Content: "@bob e @root, olhem isso"}
+	db.Create(&c)
+	svc.CommentCreated(task, c, owner)
+	var bobCount int64
+	db.Model(&models.Notification{}).Where("user_id = ?", bob.ID).Count(&bobCount)
+	if bobCount != 0 {
+		t.Fatalf("user without access must not be notified")
+	}
+	var mention models.Notification
+	if err := db.Where("user_id = ? AND type = ?", admin.ID, models.NotificationMention).First(&mention).Error; err != nil {
+		t.Fatalf("expected mention for admin: %v", err)
+	}
+}
+
+func TestPreferencesMuteType(t *testing.T) {
+	svc, db := newTestService(t)
+	owner := models.User{Name: "Ana", Email: "ana@example.com"}
+	db.Create(&owner)
+	p := models.DefaultNotificationPreference(owner.ID)
+	p.Channels[models.NotificationStatusChanged] = []string{}
+	db.Create(&p)
+	task := models.Task{Title: "X", Status: models.StatusDoing, OwnerID: owner.ID}
+	db.Create(&task)
+
+	svc.StatusChanged(task, models.StatusTodo, 999)
+	var n int64
+	db.Model(&models.Notification{}).Count(&n)
+	if n != 0 {
+		t.Fatalf("muted type must not produce notifications, got %d", n)
+	}
+}
+
+func TestQuietHoursSilencePushButNotListeners(t *testing.T) {
+	svc, db := newTestService(t)
+	hub := ws.NewHub()
+	heard := make(chan ws.Event, 4)
+	hub.AddListener(func(ev ws.Event) {
+		if ev.Type == "notification.created" {
+			heard <- ev
+		}
+	})
+	go hub.Run()
+	svc.hub = hub
+
+	owner := models.User{Name: "Ana", Email: "ana@example.com"}
+	db.Create(&owner)
+	p := models.DefaultNotificationPreference(owner.ID)
+	now := time.Now().UTC()
+	p.QuietHoursStart, p.QuietHoursEnd = now.Add(-time.Hour).Format("15:04"), now.Add(time.Hour).Format("15:04")
+	db.Create(&p)
+	sub := hub.Subscribe(owner.ID, "user", 0, 0)
+	defer hub.Unsubscribe(sub)
+	task := models.Task{Title: "X", Status: models.StatusDoing, OwnerID: owner.ID}
+	db.Create(&task)
+
+	svc.StatusChanged(task, models.StatusTodo, 999)
+	select {
+	case ev := <-heard:
+		if ev.Type != "notification.created" || !ev.Silent {
+			t.Fatalf("listeners must get the silent notification, got %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("quiet hours must not hide the notification from webhooks and chat")
+	}
+	deadline := time.After(100 * time.Millisecond)
+	for {
+		select {
+		case ev := <-sub.Events():
+			if ev.Type == "notification.created" {
+				t.Fatalf("no push during quiet hours, got %+v", ev)
+			}
+		case <-deadline:
+			return
+		}
+	}
+}
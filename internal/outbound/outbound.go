@@ -0,0 +1,114 @@
+// Package outbound guarda as requisições que o servidor faz para URLs dos usuários
+// (webhooks, canais de chat): só endereços públicos, checados no cadastro e de novo
+// a cada conexão, para que DNS rebinding ou redirecionamentos não cheguem à rede interna.
+package outbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+const (
+	lookupTimeout = 5 * time.Second
+	maxRedirects  = 5
+)
+
+var (
+	ErrURL     = errors.New("url must be http or https with a host")
+	ErrBlocked = errors.New("url must point to a public address")
+)
+
+// lookup é trocado nos testes
+var lookup = net.DefaultResolver.LookupIPAddr
+
+// Blocked diz se o endereço é da própria máquina ou de rede interna.
+func Blocked(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified()
+}
+
+// CheckURL valida o esquema e resolve o host: todos os endereços precisam ser públicos.
+func CheckURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrURL
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil {
+		if Blocked(ip) {
+			return ErrBlocked
+		}
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+	addrs, err := lookup(ctx, u.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("cannot resolve host %q", u.Hostname())
+	}
+	for _, a := range addrs {
+		if Blocked(a.IP) {
+			return ErrBlocked
+		}
+	}
+	return nil
+}
+
+// Client é o cliente HTTP para URLs de usuários: recusa conectar em endereços bloqueados
+// (o IP é checado depois da resolução, na hora do connect) e não segue redirecionamento
+// para fora de http/https. Não usa proxy do ambiente, senão a checagem seria no proxy.
+func Client(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: control}
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: timeout,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return errors.New("too many redirects")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return ErrURL
+			}
+			if ip := net.ParseIP(req.URL.Hostname()); ip != nil && Blocked(ip) {
+				return ErrBlocked
+			}
+			return nil
+		},
+	}
+}
+
+func control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || Blocked(ip) {
+		return ErrBlocked
+	}
+	return nil
+}
+
+// Error é a falha de entrega como o dono da URL a vê: só o status HTTP da resposta
+// (0 = sem resposta). O erro de rede fica no log, não vira oráculo de varredura de portas.
+type Error struct {
+	Status int
+}
+
+func (e *Error) Error() string {
+	if e.Status == 0 {
+		return "delivery failed"
+	}
+	return fmt.Sprintf("delivery failed: status %d", e.Status)
+}
@@ -0,0 +1,53 @@
+package outbound
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCheckURL(t *testing.T) {
+	lookup = func(_ context.Context, host string) ([]net.IPAddr, error) {
+		switch host {
+		case "hooks.example.com":
+			return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}}, nil
+		case "rebind.example.com":
+			return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}, {IP: net.ParseIP("10.0.0.7")}}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+	defer func() { lookup = net.DefaultResolver.LookupIPAddr }()
+
+	for raw, ok := range map[string]bool{
+		"https://hooks.example.com/x":              true,
+		"http://93.184.216.34:8080/":               true,
+		"ftp://hooks.example.com":                  false,
+		"https://":                                 false,
+		"http://127.0.0.1:5432":                    false,
+		"http://[::1]/":                            false,
+		"http://10.1.2.3/":                         false,
+		"http://192.168.0.1/":                      false,
+		"http://169.254.169.254/latest/meta-data/": false,
+		"http://0.0.0.0/":                          false,
+		"http://[::ffff:127.0.0.1]/":               false,
+		"http://rebind.example.com/":               false,
+		"http://db.internal/":                      false,
+	} {
+		if err := CheckURL(raw); (err == nil) != ok {
+			t.Errorf("%s: got %v", raw, err)
+		}
+	}
+}
+
+func TestClientRefusesInternalAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	// o nome resolve na hora do connect: a checagem é no IP de fato conectado
+	if _, err := Client(time.Second).Get(srv.URL); !errors.Is(err, ErrBlocked) {
+		t.Fatalf("expected connection to loopback to be refused, got %v", err)
+	}
+}
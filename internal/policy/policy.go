@@ -0,0 +1,178 @@
+// Package policy concentra quem pode ver e alterar cada tarefa. O papel de um usuário
+// numa tarefa é o maior entre: dono da tarefa, papel no projeto dela, responsável
+// (edita) e observador (vê). Nada atravessa a organização: fora dela o papel é None.
+// O org_admin vale como dono em tudo da sua organização; o admin (super-admin), em tudo.
+package policy
+
+import (
+	"gorm.io/gorm"
+
+	"goTasks/internal/models"
+)
+
+// Role é o papel efetivo numa tarefa ou projeto; cada papel inclui o anterior.
+type Role int
+
+const (
+	None   Role = iota
+	Viewer      // vê, comenta e cria lembretes
+	Editor      // também edita campos, checklist, subtarefas e dependências
+	Owner       // também exclui, transfere, escolhe responsáveis e gerencia o projeto
+)
+
+// Actor é quem faz a requisição.
+type Actor struct {
+	ID       uint
+	OrgID    uint
+	Admin    bool // super-admin da instância
+	OrgAdmin bool // administra a própria organização
+}
+
+// ProjectRole converte o papel gravado em ProjectMember.
+func ProjectRole(role string) Role {
+	switch role {
+	case models.ProjectOwner:
+		return Owner
+	case models.ProjectEditor:
+		return Editor
+	case models.ProjectViewer:
+		return Viewer
+	}
+	return None
+}
+
+// OnProject devolve o papel do ator no projeto.
+func OnProject(db *gorm.DB, a Actor, projectID uint) (Role, error) {
+	if a.Admin {
+		return Owner, nil
+	}
+	var p models.Project
+	if err := db.Select("id", "org_id").Limit(1).Find(&p, "id = ?", projectID).Error; err != nil {
+		return None, err
+	}
+	if p.ID == 0 || p.OrgID != a.OrgID {
+		return None, nil
+	}
+	if a.OrgAdmin {
+		return Owner, nil
+	}
+	var m models.ProjectMember
+	err := db.Limit(1).Find(&m, "project_id = ? AND user_id = ?", projectID, a.ID).Error
+	if err != nil {
+		return None, err
+	}
+	return ProjectRole(m.Role), nil
+}
+
+// OnTask devolve o papel do ator na tarefa.
+func OnTask(db *gorm.DB, a Actor, task models.Task) (Role, error) {
+	if a.Admin {
+		return Owner, nil
+	}
+	if task.OrgID != a.OrgID {
+		return None, nil
+	}
+	if a.OrgAdmin || (a.ID != 0 && task.OwnerID == a.ID) {
+		return Owner, nil
+	}
+	role := None
+	if task.ProjectID != nil {
+		r, err := OnProject(db, a, *task.ProjectID)
+		if err != nil {
+			return None, err
+		}
+		role = r
+	}
+	if role >= Editor {
+		return role, nil
+	}
+	assignees, watchers, err := models.TaskMembers(db, task.ID)
+	if err != nil {
+		return None, err
+	}
+	switch {
+	case contains(assignees, a.ID):
+		return Editor, nil
+	case contains(watchers, a.ID) && role < Viewer:
+		return Viewer, nil
+	}
+	return role, nil
+}
+
+// Can informa se o ator tem pelo menos o papel need na tarefa.
+func Can(db *gorm.DB, a Actor, task models.Task, need Role) (bool, error) {
+	role, err := OnTask(db, a, task)
+	return err == nil && role >= need, err
+}
+
+// VisibleTaskIDs é a subconsulta das tarefas que o ator (não super-admin) vê, sempre dentro
+// da organização dele: todas, para o org_admin; senão as dele, as dos projetos de que é
+// membro, as atribuídas a ele e as que observa.
+func VisibleTaskIDs(db *gorm.DB, a Actor) *gorm.DB {
+	qry := db.Model(&models.Task{}).Select("id").Where("org_id = ?", a.OrgID)
+	if a.OrgAdmin {
+		return qry
+	}
+	return qry.Where(db.Where("owner_id = ?", a.ID).
+		Or("project_id IN (?)", db.Model(&models.ProjectMember{}).Select("project_id").Where("user_id = ?", a.ID)).
+		Or("id IN (?)", db.Table(models.TaskAssigneesTable).Select("task_id").Where("user_id = ?", a.ID)).
+		Or("id IN (?)", db.Table(models.TaskWatchersTable).Select("task_id").Where("user_id = ?", a.ID)))
+}
+
+// OwnedTaskIDs é a subconsulta das tarefas, inclusive as da lixeira, em que o ator (não
+// super-admin) tem papel de dono: as dele e as dos projetos de que é dono; para o org_admin,
+// todas da organização.
+func OwnedTaskIDs(db *gorm.DB, a Actor) *gorm.DB {
+	qry := db.Unscoped().Model(&models.Task{}).Select("id").Where("org_id = ?", a.OrgID)
+	if a.OrgAdmin {
+		return qry
+	}
+	return qry.Where(db.Where("owner_id = ?", a.ID).
+		Or("project_id IN (?)", db.Model(&models.ProjectMember{}).Select("project_id").Where("user_id = ? AND role = ?", a.ID, models.ProjectOwner)))
+}
+
+// Audience lista quem vê a tarefa e deve receber seus eventos, incluindo os org_admins
+// da organização (super-admins recebem tudo no hub).
+func Audience(db *gorm.DB, task models.Task, extra ...uint) []uint {
+	ids := append([]uint{task.OwnerID}, extra...)
+	var admins []uint
+	if err := db.Model(&models.User{}).Where("org_id = ? AND role = ?", task.OrgID, models.RoleOrgAdmin).Pluck("id", &admins).Error; err == nil {
+		ids = append(ids, admins...)
+	}
+	if assignees, watchers, err := models.TaskMembers(db, task.ID); err == nil {
+		ids = append(append(ids, assignees...), watchers...)
+	}
+	if task.ProjectID != nil {
+		var members []uint
+		if err := db.Model(&models.ProjectMember{}).Where("project_id = ?", *task.ProjectID).Pluck("user_id", &members).Error; err == nil {
+			ids = append(ids, members...)
+		}
+	}
+	return ids
+}
+
+// VisibleTo filtra, entre ids, os usuários que podem ver a tarefa; quem é de outra
+// organização nunca passa (nem se estiver em extra de Audience).
+func VisibleTo(db *gorm.DB, task models.Task, ids []uint) []uint {
+	if len(ids) == 0 {
+		return nil
+	}
+	audience := Audience(db, task)
+	var out []uint
+	if err := db.Model(&models.User{}).
+		Where("id IN ?", ids).
+		Where("(org_id = ? AND id IN ?) OR role = ?", task.OrgID, audience, models.RoleAdmin).
+		Pluck("id", &out).Error; err != nil {
+		return nil
+	}
+	return out
+}
+
+func contains(ids []uint, id uint) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
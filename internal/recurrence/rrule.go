@@ -0,0 +1,255 @@
+// Package recurrence implementa o subconjunto de RRULE (RFC 5545) usado nas tarefas
+// recorrentes: FREQ=DAILY|WEEKLY|MONTHLY, INTERVAL, BYDAY (semanal), BYMONTHDAY
+// (mensal), COUNT e UNTIL.
+package recurrence
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Freq string
+
+const (
+	Daily   Freq = "DAILY"
+	Weekly  Freq = "WEEKLY"
+	Monthly Freq = "MONTHLY"
+)
+
+// maxPeriods evita laços sem fim em regras que nunca casam (ex.: BYMONTHDAY=31 só em meses curtos).
+const maxPeriods = 1000
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// Rule é uma RRULE interpretada. As ocorrências partem de um início (DTSTART),
+// de onde vêm a hora do dia e os padrões de BYDAY/BYMONTHDAY.
+type Rule struct {
+	Freq       Freq
+	Interval   int
+	ByDay      []time.Weekday
+	ByMonthDay []int
+	Count      int        // 0 = sem limite
+	Until      *time.Time // inclusivo
+}
+
+// Parse lê uma regra como "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;UNTIL=20261231T235959Z".
+// O prefixo "RRULE:" é opcional.
+func Parse(s string) (Rule, error) {
+	r := Rule{Interval: 1}
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return r, fmt.Errorf("empty rule")
+	}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(s, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return r, fmt.Errorf("invalid part %q", part)
+		}
+		key, val := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+		if seen[key] {
+			return r, fmt.Errorf("duplicate %s", key)
+		}
+		seen[key] = true
+		switch key {
+		case "FREQ":
+			switch Freq(val) {
+			case Daily, Weekly, Monthly:
+				r.Freq = Freq(val)
+			default:
+				return r, fmt.Errorf("unsupported FREQ %s", val)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 || n > 366 {
+				return r, fmt.Errorf("invalid INTERVAL %s", val)
+			}
+			r.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return r, fmt.Errorf("invalid COUNT %s", val)
+			}
+			r.Count = n
+		case "UNTIL":
+			t, err := parseUntil(val)
+			if err != nil {
+				return r, fmt.Errorf("invalid UNTIL %s", val)
+			}
+			r.Until = &t
+		case "BYDAY":
+			for _, d := range strings.Split(val, ",") {
+				wd, ok := weekdays[d]
+				if !ok {
+					return r, fmt.Errorf("invalid BYDAY %s", d)
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		case "BYMONTHDAY":
+			for _, d := range strings.Split(val, ",") {
+				n, err := strconv.Atoi(d)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return r, fmt.Errorf("invalid BYMONTHDAY %s", d)
+				}
+				r.ByMonthDay = append(r.ByMonthDay, n)
+			}
+		default:
+			return r, fmt.Errorf("unsupported %s", key)
+		}
+	}
+	if r.Freq == "" {
+		return r, fmt.Errorf("FREQ is required")
+	}
+	if r.Count > 0 && r.Until != nil {
+		return r, fmt.Errorf("COUNT and UNTIL are mutually exclusive")
+	}
+	if len(r.ByDay) > 0 && r.Freq != Weekly {
+		return r, fmt.Errorf("BYDAY is only supported with FREQ=WEEKLY")
+	}
+	if len(r.ByMonthDay) > 0 && r.Freq != Monthly {
+		return r, fmt.Errorf("BYMONTHDAY is only supported with FREQ=MONTHLY")
+	}
+	return r, nil
+}
+
+func parseUntil(v string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102"} {
+		if t, err := time.ParseInLocation(layout, v, time.UTC); err == nil {
+			if layout == "20060102" {
+				// data sem hora: vale o dia inteiro
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date")
+}
+
+// String devolve a forma canônica da regra.
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		names := make([]string, 0, len(r.ByDay))
+		for _, d := range r.ByDay {
+			names = append(names, strings.ToUpper(d.String()[:2]))
+		}
+		parts = append(parts, "BYDAY="+strings.Join(names, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, 0, len(r.ByMonthDay))
+		for _, d := range r.ByMonthDay {
+			days = append(days, strconv.Itoa(d))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next devolve a primeira ocorrência estritamente depois de after, para uma série
+// que começa em start (a própria start é a ocorrência 1). COUNT é responsabilidade
+// de quem chama, que sabe quantas ocorrências já existem; UNTIL é aplicado aqui.
+func (r Rule) Next(start, after time.Time) (time.Time, bool) {
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+	// pula direto para perto de after (com um período de folga para DST)
+	first := r.elapsed(start, after)/interval - 1
+	if first < 0 {
+		first = 0
+	}
+	for p := first; p < first+maxPeriods; p++ {
+		for _, t := range r.period(start, p*interval) {
+			if t.Before(start) || !t.After(after) {
+				continue
+			}
+			if r.Until != nil && t.After(*r.Until) {
+				return time.Time{}, false
+			}
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// elapsed conta quantos dias, semanas ou meses inteiros separam start de t.
+func (r Rule) elapsed(start, t time.Time) int {
+	if !t.After(start) {
+		return 0
+	}
+	switch r.Freq {
+	case Daily:
+		return int(t.Sub(start).Hours() / 24)
+	case Weekly:
+		return int(t.Sub(start).Hours() / (24 * 7))
+	case Monthly:
+		return (t.Year()-start.Year())*12 + int(t.Month()) - int(start.Month())
+	}
+	return 0
+}
+
+// period lista, em ordem, as ocorrências do n-ésimo período (dia, semana ou mês) a partir de start.
+func (r Rule) period(start time.Time, n int) []time.Time {
+	y, m, d := start.Date()
+	h, mi, s := start.Clock()
+	loc := start.Location()
+	at := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, h, mi, s, 0, loc) }
+
+	switch r.Freq {
+	case Daily:
+		return []time.Time{at(y, m, d+n)}
+	case Weekly:
+		days := r.ByDay
+		if len(days) == 0 {
+			days = []time.Weekday{start.Weekday()}
+		}
+		// semana começando na segunda (WKST=MO)
+		monday := d - (int(start.Weekday())+6)%7 + 7*n
+		out := make([]time.Time, 0, len(days))
+		for _, wd := range days {
+			out = append(out, at(y, m, monday+(int(wd)+6)%7))
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+		return out
+	case Monthly:
+		first := at(y, m+time.Month(n), 1)
+		last := daysIn(first.Year(), first.Month())
+		days := r.ByMonthDay
+		if len(days) == 0 {
+			days = []int{d}
+		}
+		out := make([]time.Time, 0, len(days))
+		for _, md := range days {
+			if md < 0 {
+				md = last + md + 1
+			}
+			// meses sem o dia (ex.: 31 em abril) são pulados, como na RFC
+			if md < 1 || md > last {
+				continue
+			}
+			out = append(out, at(first.Year(), first.Month(), md))
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+		return out
+	}
+	return nil
+}
+
+func daysIn(y int, m time.Month) int {
+	return time.Date(y, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
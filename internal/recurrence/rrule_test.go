@@ -0,0 +1,56 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAndString(t *testing.T) {
+	r, err := Parse("RRULE:freq=weekly;interval=2;byday=MO,FR;until=20261231")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.String(); got != "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;UNTIL=20261231T235959Z" {
+		t.Fatalf("unexpected canonical form %q", got)
+	}
+	for _, bad := range []string{"", "FREQ=YEARLY", "FREQ=DAILY;BYDAY=MO", "FREQ=DAILY;COUNT=2;UNTIL=20260101", "INTERVAL=2", "FREQ=MONTHLY;BYMONTHDAY=32"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// quarta-feira, 9h
+	start := time.Date(2026, 1, 7, 9, 0, 0, 0, time.UTC)
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 9, 0, 0, 0, time.UTC) }
+	cases := []struct {
+		rule  string
+		after time.Time
+		want  time.Time
+		ok    bool
+	}{
+		{"FREQ=DAILY", start, day(2026, 1, 8), true},
+		{"FREQ=DAILY;INTERVAL=3", day(2026, 1, 8), day(2026, 1, 10), true},
+		{"FREQ=WEEKLY", start, day(2026, 1, 14), true},
+		{"FREQ=WEEKLY;BYDAY=MO,FR", start, day(2026, 1, 9), true},
+		{"FREQ=WEEKLY;BYDAY=MO,FR", day(2026, 1, 9), day(2026, 1, 12), true},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE", start, day(2026, 1, 19), true},
+		{"FREQ=MONTHLY", start, day(2026, 2, 7), true},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", start, day(2026, 1, 31), true},
+		{"FREQ=MONTHLY;BYMONTHDAY=31", day(2026, 1, 31), day(2026, 3, 31), true},
+		{"FREQ=DAILY;UNTIL=20260108", day(2026, 1, 8), time.Time{}, false},
+		// muito depois do início continua eficiente e alinhado ao intervalo
+		{"FREQ=DAILY;INTERVAL=2", day(2031, 1, 1), day(2031, 1, 3), true},
+	}
+	for _, c := range cases {
+		r, err := Parse(c.rule)
+		if err != nil {
+			t.Fatalf("%s: %v", c.rule, err)
+		}
+		got, ok := r.Next(start, c.after)
+		if ok != c.ok || !got.Equal(c.want) {
+			t.Errorf("%s after %s: got %s %v, want %s %v", c.rule, c.after.Format(time.DateOnly), got, ok, c.want, c.ok)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Package trash cuida da lixeira: a retenção das tarefas excluídas e a limpeza
+// definitiva das que passaram do prazo, feita só pela réplica líder.
+package trash
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"goTasks/internal/leader"
+	"goTasks/internal/models"
+)
+
+const (
+	DefaultRetentionDays = 30
+	MaxRetentionDays     = 3650
+
+	retentionKey   = "trash.retention_days"
+	purgeInterval  = time.Hour
+	purgeLeaseTTL  = 90 * time.Minute
+	purgeBatchSize = 500
+)
+
+var ErrRetention = errors.New("retentionDays must be between 1 and 3650")
+
+// Retention devolve por quantos dias uma tarefa excluída fica na lixeira.
+func Retention(db *gorm.DB) (int, error) {
+	var s models.Setting
+	if err := db.Limit(1).Find(&s, "key = ?", retentionKey).Error; err != nil {
+		return 0, err
+	}
+	days, err := strconv.Atoi(s.Value)
+	if err != nil || days < 1 {
+		return DefaultRetentionDays, nil
+	}
+	return days, nil
+}
+
+// SetRetention grava o novo prazo; vale a partir da próxima limpeza.
+func SetRetention(db *gorm.DB, days int) error {
+	if days < 1 || days > MaxRetentionDays {
+		return ErrRetention
+	}
+	return db.Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(&models.Setting{Key: retentionKey, Value: strconv.Itoa(days)}).Error
+}
+
+// PurgeTasks apaga de vez as tarefas e o que pende delas: comentários, notificações,
+// lembretes, checklist, dependências e vínculos. O histórico fica (é a trilha de auditoria).
+func PurgeTasks(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	for _, table := range []string{"task_labels", models.TaskAssigneesTable, models.TaskWatchersTable} {
+		if err := tx.Exec("DELETE FROM "+table+" WHERE task_id IN ?", ids).Error; err != nil {
+			return err
+		}
+	}
+	for _, m := range []interface{}{&models.TaskReminder{}, &models.ChecklistItem{}, &models.Comment{}, &models.Notification{}} {
+		if err := tx.Unscoped().Where("task_id IN ?", ids).Delete(m).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Where("task_id IN ? OR blocker_id IN ?", ids, ids).Delete(&models.TaskDependency{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("id IN ?", ids).Delete(&models.Task{}).Error
+}
+
+// Purge apaga de vez o que está na lixeira há mais que a retenção e devolve quantas tarefas saíram.
+func Purge(db *gorm.DB, now time.Time) (int, error) {
+	days, err := Retention(db)
+	if err != nil {
+		return 0, err
+	}
+	cutoff := now.AddDate(0, 0, -days)
+	purged := 0
+	for {
+		var ids []uint
+		if err := db.Unscoped().Model(&models.Task{}).Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Order("id").Limit(purgeBatchSize).Pluck("id", &ids).Error; err != nil {
+			return purged, err
+		}
+		if len(ids) == 0 {
+			break
+		}
+		if err := db.Transaction(func(tx *gorm.DB) error { return PurgeTasks(tx, ids) }); err != nil {
+			return purged, err
+		}
+		purged += len(ids)
+	}
+	// comentários excluídos sozinhos seguem o mesmo prazo
+	err = db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Delete(&models.Comment{}).Error
+	return purged, err
+}
+
+// Purger roda a limpeza de hora em hora na réplica que detém o arrendamento.
+type Purger struct {
+	db    *gorm.DB
+	lease *leader.Lease
+	stop  chan struct{}
+}
+
+func NewPurger(db *gorm.DB) *Purger {
+	return &Purger{db: db, lease: leader.NewLease(db, "trash.purge", purgeLeaseTTL), stop: make(chan struct{})}
+}
+
+func (p *Purger) Start() {
+	go func() {
+		ticker := time.NewTicker(purgeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.tick()
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (p *Purger) Stop() {
+	close(p.stop)
+	if err := p.lease.Release(); err != nil {
+		log.Printf("trash lease release error: %v", err)
+	}
+}
+
+func (p *Purger) tick() {
+	ok, err := p.lease.TryAcquire()
+	if err != nil {
+		log.Printf("trash lease error: %v", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	now := time.Now()
+	n, runErr := Purge(p.db, now)
+	if runErr != nil {
+		log.Printf("trash purge error: %v", runErr)
+	} else if n > 0 {
+		log.Printf("trash purge: %d tasks removed", n)
+	}
+	if err := p.lease.RecordRun(now, runErr); err != nil {
+		log.Printf("trash lease record error: %v", err)
+	}
+}
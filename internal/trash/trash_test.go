@@ -0,0 +1,70 @@
+package trash
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"goTasks/internal/models"
+)
+
+func TestPurgeRemovesExpiredTasksWithTheirData(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&models.Task{}, &models.Comment{}, &models.Notification{}, &models.TaskReminder{},
+		&models.ChecklistItem{}, &models.TaskDependency{}, &models.Label{}, &models.User{}, &models.Setting{}); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	task := func(title string, deletedDaysAgo int) uint {
+		tk := models.Task{Title: title, Status: models.StatusTodo}
+		db.Create(&tk)
+		db.Create(&models.Comment{TaskID: tk.ID, Content: "c"})
+		db.Create(&models.Notification{TaskID: tk.ID, UserID: 1, Type: models.NotificationComment})
+		db.Create(&models.TaskReminder{TaskID: tk.ID, UserID: 1})
+		if deletedDaysAgo > 0 {
+			at := now.AddDate(0, 0, -deletedDaysAgo)
+			db.Model(&models.Task{}).Where("id = ?", tk.ID).UpdateColumn("deleted_at", at)
+			db.Model(&models.Comment{}).Where("task_id = ?", tk.ID).UpdateColumn("deleted_at", at)
+		}
+		return tk.ID
+	}
+	old, recent, live := task("antiga", 40), task("recente", 10), task("viva", 0)
+
+	if n, err := Purge(db, now); err != nil || n != 1 {
+		t.Fatalf("only the task past the default retention goes: n=%d err=%v", n, err)
+	}
+	count := func(m interface{}, taskID uint) int64 {
+		var n int64
+		db.Unscoped().Model(m).Where("task_id = ?", taskID).Count(&n)
+		return n
+	}
+	for _, m := range []interface{}{&models.Comment{}, &models.Notification{}, &models.TaskReminder{}} {
+		if count(m, old) != 0 || count(m, recent) != 1 || count(m, live) != 1 {
+			t.Fatalf("%T must cascade only with the purged task", m)
+		}
+	}
+	var tasks int64
+	if db.Unscoped().Model(&models.Task{}).Where("id = ?", old).Count(&tasks); tasks != 0 {
+		t.Fatal("the expired task must be deleted for good")
+	}
+
+	if err := SetRetention(db, 0); err != ErrRetention {
+		t.Fatalf("retention must be validated, got %v", err)
+	}
+	if err := SetRetention(db, 7); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := Purge(db, now); n != 1 {
+		t.Fatalf("a shorter retention purges the recent task too, got %d", n)
+	}
+	if db.Model(&models.Task{}).Where("id = ?", live).Count(&tasks); tasks != 1 {
+		t.Fatal("live tasks are never purged")
+	}
+}
@@ -0,0 +1,369 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"goTasks/internal/models"
+	"goTasks/internal/notify"
+	"goTasks/internal/outbound"
+	"goTasks/internal/ws"
+)
+
+const (
+	maxAttempts     = 8
+	disableAfter    = 20 // tentativas falhas seguidas até desativar o webhook
+	batchSize       = 50
+	sendTimeout     = 10 * time.Second
+	maxResponseBody = 1024
+)
+
+// Events lista os eventos do hub que podem ser assinados.
+var Events = []string{"task.created", "task.updated", "task.deleted", "comment.created", "notification.created"}
+
+// Cabeçalhos enviados em cada entrega
+const (
+	HeaderEvent     = "X-GoTasks-Event"
+	HeaderDelivery  = "X-GoTasks-Delivery"
+	HeaderTimestamp = "X-GoTasks-Timestamp"
+	HeaderSignature = "X-GoTasks-Signature"
+)
+
+// Sign calcula a assinatura "sha256=<hex>" de HMAC-SHA256(secret, "<timestamp>.<body>").
+// O receptor deve recalcular e rejeitar timestamps antigos para evitar replay.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret gera o segredo de assinatura de um webhook.
+func NewSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Supported diz se o evento pode ser assinado.
+func Supported(event string) bool {
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// envelope é o corpo enviado ao receptor.
+type envelope struct {
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      interface{} `json:"data"`
+}
+
+// Dispatcher transforma eventos do hub em entregas persistidas e as envia com retentativas.
+type Dispatcher struct {
+	db     *gorm.DB
+	client *http.Client
+	events chan ws.Event
+	kick   chan struct{}
+	stop   chan struct{}
+}
+
+func NewDispatcher(db *gorm.DB) *Dispatcher {
+	return &Dispatcher{
+		db:     db,
+		client: outbound.Client(sendTimeout),
+		events: make(chan ws.Event, 256),
+		kick:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+	}
+}
+
+// Listen assina os eventos do hub (chamar antes de hub.Run).
+func (d *Dispatcher) Listen(hub *ws.Hub) {
+	hub.AddListener(func(ev ws.Event) {
+		if !Supported(ev.Type) {
+			return
+		}
+		select {
+		case d.events <- ev:
+		default:
+			log.Printf("webhook: event %d (%s) dropped, queue full", ev.ID, ev.Type)
+		}
+	})
+}
+
+func (d *Dispatcher) Start() {
+	go func() {
+		for {
+			select {
+			case ev := <-d.events:
+				if err := d.enqueue(ev); err != nil {
+					log.Printf("webhook enqueue error: %v", err)
+				}
+			case <-d.stop:
+				return
+			}
+		}
+	}()
+	go func() {
+		ticker := time.NewTicker(15 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-d.kick:
+			case <-d.stop:
+				return
+			}
+			if _, err := d.processDue(context.Background()); err != nil {
+				log.Printf("webhook queue error: %v", err)
+			}
+		}
+	}()
+}
+
+func (d *Dispatcher) Stop() {
+	close(d.stop)
+}
+
+// wake pede uma rodada de envio sem esperar o próximo tick.
+func (d *Dispatcher) wake() {
+	select {
+	case d.kick <- struct{}{}:
+	default:
+	}
+}
+
+// Redeliver cria uma nova entrega com o mesmo corpo de uma anterior.
+func (d *Dispatcher) Redeliver(prev models.WebhookDelivery) (models.WebhookDelivery, error) {
+	id := prev.ID
+	next := models.WebhookDelivery{
+		WebhookID:     prev.WebhookID,
+		EventType:     prev.EventType,
+		Payload:       prev.Payload,
+		Status:        models.WebhookPending,
+		NextAttemptAt: time.Now(),
+		RedeliveryOf:  &id,
+	}
+	if err := d.db.Create(&next).Error; err != nil {
+		return next, err
+	}
+	d.wake()
+	return next, nil
+}
+
+func (d *Dispatcher) enqueue(ev ws.Event) error {
+	hooks, err := d.subscribers(ev)
+	if err != nil || len(hooks) == 0 {
+		return err
+	}
+	body, err := json.Marshal(envelope{Type: ev.Type, CreatedAt: time.Now().UTC(), Data: ev.Payload})
+	if err != nil {
+		return err
+	}
+	for _, h := range hooks {
+		del := models.WebhookDelivery{
+			WebhookID:     h.ID,
+			EventType:     ev.Type,
+			Payload:       string(body),
+			Status:        models.WebhookPending,
+			NextAttemptAt: time.Now(),
+		}
+		if err := d.db.Create(&del).Error; err != nil {
+			return err
+		}
+	}
+	d.wake()
+	return nil
+}
+
+// subscribers aplica ao webhook a mesma visibilidade do /ws (To ou admin).
+// Notificações são pessoais: só vão para webhooks do destinatário que ativou o canal webhook.
+func (d *Dispatcher) subscribers(ev ws.Event) ([]models.Webhook, error) {
+	q := d.db.Where("active = ?", true)
+	if ev.Type == "notification.created" {
+		n, ok := ev.Payload.(models.Notification)
+		if !ok {
+			return nil, nil
+		}
+		p, err := notify.LoadPreferences(d.db, n.UserID)
+		if err != nil || !p.Wants(n.Type, models.ChannelWebhook) {
+			return nil, err
+		}
+		q = q.Where("user_id = ?", n.UserID)
+	} else if len(ev.To) > 0 {
+		admins := d.db.Model(&models.User{}).Select("id").Where("role = ?", "admin")
+		q = q.Where("user_id IN ? OR user_id IN (?)", ev.To, admins)
+	}
+	var hooks []models.Webhook
+	if err := q.Find(&hooks).Error; err != nil {
+		return nil, err
+	}
+	out := hooks[:0]
+	for _, h := range hooks {
+		if len(h.Events) == 0 || contains(h.Events, ev.Type) {
+			out = append(out, h)
+		}
+	}
+	return out, nil
+}
+
+// processDue envia as entregas vencidas; devolve quantas foram aceitas pelo receptor.
+func (d *Dispatcher) processDue(ctx context.Context) (int, error) {
+	var items []models.WebhookDelivery
+	active := d.db.Model(&models.Webhook{}).Select("id").Where("active = ?", true)
+	if err := d.db.
+		Where("status = ? AND next_attempt_at <= ?", models.WebhookPending, time.Now()).
+		Where("webhook_id IN (?)", active).
+		Order("next_attempt_at").
+		Limit(batchSize).
+		Find(&items).Error; err != nil {
+		return 0, err
+	}
+
+	hooks := map[uint]models.Webhook{}
+	sent := 0
+	for _, del := range items {
+		// reserva a entrega incrementando attempts; outra réplica que chegue junto perde a corrida
+		res := d.db.Model(&models.WebhookDelivery{}).
+			Where("id = ? AND status = ? AND attempts = ?", del.ID, models.WebhookPending, del.Attempts).
+			Updates(map[string]interface{}{
+				"attempts":        del.Attempts + 1,
+				"next_attempt_at": time.Now().Add(backoff(del.Attempts + 1)),
+			})
+		if res.Error != nil {
+			return sent, res.Error
+		}
+		if res.RowsAffected == 0 {
+			continue
+		}
+		del.Attempts++
+
+		h, ok := hooks[del.WebhookID]
+		if !ok {
+			if err := d.db.First(&h, del.WebhookID).Error; err != nil {
+				return sent, err
+			}
+			hooks[h.ID] = h
+		}
+		if !h.Active {
+			continue
+		}
+
+		status, err := d.send(ctx, h, del)
+		updates := map[string]interface{}{
+			"response_status": status,
+			"last_error":      "",
+		}
+		if err == nil {
+			now := time.Now()
+			updates["status"] = models.WebhookSent
+			updates["delivered_at"] = &now
+			sent++
+		} else {
+			// o dono só vê o status; o erro de rede fica no log
+			updates["last_error"] = (&outbound.Error{Status: status}).Error()
+			if del.Attempts >= maxAttempts {
+				updates["status"] = models.WebhookFailed
+			}
+			log.Printf("webhook %d delivery %d attempt %d failed: %v", h.ID, del.ID, del.Attempts, err)
+		}
+		if err := d.db.Model(&models.WebhookDelivery{}).Where("id = ?", del.ID).Updates(updates).Error; err != nil {
+			return sent, err
+		}
+		if h, err = d.recordResult(h, err == nil); err != nil {
+			return sent, err
+		}
+		hooks[h.ID] = h
+	}
+	return sent, nil
+}
+
+// recordResult zera o contador de falhas no sucesso; na falha soma e desativa ao atingir o limite.
+func (d *Dispatcher) recordResult(h models.Webhook, ok bool) (models.Webhook, error) {
+	if ok {
+		if h.FailureCount == 0 {
+			return h, nil
+		}
+		h.FailureCount = 0
+		return h, d.db.Model(&h).Update("failure_count", 0).Error
+	}
+	if err := d.db.Model(&h).Update("failure_count", gorm.Expr("failure_count + 1")).Error; err != nil {
+		return h, err
+	}
+	h.FailureCount++
+	if h.FailureCount < disableAfter {
+		return h, nil
+	}
+	now := time.Now()
+	h.Active, h.DisabledAt = false, &now
+	log.Printf("webhook %d disabled after %d consecutive failures", h.ID, h.FailureCount)
+	return h, d.db.Model(&h).Updates(map[string]interface{}{"active": false, "disabled_at": &now}).Error
+}
+
+// send entrega o evento e devolve o status HTTP; o corpo da resposta é descartado.
+func (d *Dispatcher) send(ctx context.Context, h models.Webhook, del models.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	body := []byte(del.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "goTasks-Webhook/1.0")
+	req.Header.Set(HeaderEvent, del.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(uint64(del.ID), 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(h.Secret, ts, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// lê um pouco para a conexão poder ser reaproveitada
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff: 30s, 1m, 2m, ... limitado a 6h.
+func backoff(attempt int) time.Duration {
+	d := 30 * time.Second << (attempt - 1)
+	if d > 6*time.Hour || d <= 0 {
+		d = 6 * time.Hour
+	}
+	return d
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"goTasks/internal/models"
+	"goTasks/internal/ws"
+)
+
+func newTestDispatcher(t *testing.T) (*Dispatcher, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&models.User{}, &models.NotificationPreference{}, &models.Webhook{}, &models.WebhookDelivery{}); err != nil {
+		t.Fatal(err)
+	}
+	return NewDispatcher(db), db
+}
+
+func TestDeliveriesAreSignedAndScopedToVisibility(t *testing.T) {
+	d, db := newTestDispatcher(t)
+	ana := models.User{Name: "Ana", Email: "ana@example.com", Role: "user"}
+	bob := models.User{Name: "Bob", Email: "bob@example.com", Role: "user"}
+	root := models.User{Name: "Root", Email: "root@example.com", Role: "admin"}
+	db.Create(&ana)
+	db.Create(&bob)
+	db.Create(&root)
+
+	var mu sync.Mutex
+	got := map[string][]string{} // path -> eventos
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		if r.Header.Get(HeaderSignature) != Sign("s"+r.URL.Path, ts, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		got[r.URL.Path] = append(got[r.URL.Path], r.Header.Get(HeaderEvent))
+		mu.Unlock()
+	}))
+	defer srv.Close()
+	// o servidor de teste é local: fora do que o cliente de produção aceita
+	d.client = srv.Client()
+
+	for _, h := range []models.Webhook{
+		{UserID: ana.ID, URL: srv.URL + "/ana", Secret: "s/ana", Active: true},
+		{UserID: bob.ID, URL: srv.URL + "/bob", Secret: "s/bob", Active: true, Events: []string{"task.created"}},
+		{UserID: root.ID, URL: srv.URL + "/root", Secret: "s/root", Active: true},
+	} {
+		db.Create(&h)
+	}
+
+	events := []ws.Event{
+		{Type: "task.created", Payload: map[string]uint{"id": 1}, To: []uint{ana.ID}},
+		{Type: "task.updated", Payload: map[string]uint{"id": 1}, To: []uint{ana.ID, bob.ID}},
+		// canal webhook não habilitado nas preferências da Ana: não vai
+		{Type: "notification.created", Payload: models.Notification{UserID: ana.ID, Type: models.NotificationComment}, To: []uint{ana.ID}},
+	}
+	for _, ev := range events {
+		if err := d.enqueue(ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := d.processDue(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got["/ana"]) != 2 || len(got["/root"]) != 2 {
+		t.Fatalf("unexpected deliveries: %v", got)
+	}
+	if len(got["/bob"]) != 0 {
+		t.Fatalf("bob subscribed only to task.created for his tasks, got %v", got["/bob"])
+	}
+	var sent int64
+	db.Model(&models.WebhookDelivery{}).Where("status = ? AND response_status = ?", models.WebhookSent, 200).Count(&sent)
+	if sent != 4 {
+		t.Fatalf("expected 4 sent deliveries, got %d", sent)
+	}
+}
+
+func TestFailingWebhookRetriesAndIsDisabled(t *testing.T) {
+	d, db := newTestDispatcher(t)
+	user := models.User{Name: "Ana", Email: "ana@example.com", Role: "user"}
+	db.Create(&user)
+
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "boom", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	d.client = srv.Client()
+	hook := models.Webhook{UserID: user.ID, URL: srv.URL, Secret: "s", Active: true}
+	db.Create(&hook)
+	drain := func() {
+		for i := 0; i < 2*disableAfter; i++ {
+			// adianta o relógio das retentativas
+			db.Model(&models.WebhookDelivery{}).Where("status = ?", models.WebhookPending).Update("next_attempt_at", time.Now().Add(-time.Second))
+			if _, err := d.processDue(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	d.enqueue(ws.Event{Type: "task.created", Payload: 1, To: []uint{user.ID}})
+	drain()
+	var failed models.WebhookDelivery
+	db.Where("status = ?", models.WebhookFailed).First(&failed)
+	if failed.Attempts != maxAttempts || failed.ResponseStatus != http.StatusBadGateway || failed.LastError != "delivery failed: status 502" {
+		t.Fatalf("expected a failed delivery with logged status, got %+v", failed)
+	}
+
+	d.enqueue(ws.Event{Type: "task.created", Payload: 2, To: []uint{user.ID}})
+	d.enqueue(ws.Event{Type: "task.created", Payload: 3, To: []uint{user.ID}})
+	drain()
+	db.First(&hook, hook.ID)
+	if hook.Active || hook.DisabledAt == nil || calls != disableAfter {
+		t.Fatalf("expected webhook disabled after %d failures, got active=%v calls=%d", disableAfter, hook.Active, calls)
+	}
+}
@@ -1,22 +1,48 @@
 package ws
 
 import (
-	"errors"
+	"encoding/json"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/websocket/v2"
-	"github.com/golang-jwt/jwt/v5"
+
+	"goTasks/internal/auth"
 )
 
+// historySize é quantos eventos recentes o hub guarda para retomada (Last-Event-ID).
+const historySize = 512
+
 type Event struct {
+	ID      uint64      `json:"id,omitempty"`
 	Type    string      `json:"type"`
 	Payload interface{} `json:"payload"`
+	// To restringe a entrega a esses usuários (admins recebem tudo). Vazio = todos.
+	To []uint `json:"-"`
+	// Org restringe a entrega aos usuários da organização, mesmo com To vazio (0 = sem restrição).
+	Org uint `json:"-"`
+	// Silent vai só aos listeners (webhooks, chat): nenhuma conexão WS/SSE recebe, nem na retomada.
+	Silent bool `json:"-"`
 }
 
 type Client struct {
-	conn *websocket.Conn
-	send chan Event
+	conn   *websocket.Conn // nil para assinantes SSE
+	send   chan Event
+	userID uint
+	role   string
+	org    uint
+	// resumeAfter: reenviar eventos do histórico com ID maior que este ao registrar
+	resumeAfter uint64
+	// closeReason é definido pelo hub antes de fechar send
+	closeReason string
+}
+
+type dropRequest struct {
+	client *Client
+	userID uint
+	reason string
 }
 
 type Hub struct {
@@ -24,15 +50,27 @@ type Hub struct {
 	register   chan *Client
 	unregister chan *Client
 	broadcast  chan Event
+	drop       chan dropRequest
+	presence   *Presence
+	seq        uint64
+	history    []Event
+	listeners  []func(Event)
+	// sessionCheck é o intervalo de reverificação de revogação (os testes encurtam)
+	sessionCheck time.Duration
 }
 
 func NewHub() *Hub {
-	return &Hub{
+	h := &Hub{
 		clients:    make(map[*Client]bool),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 		broadcast:  make(chan Event, 128),
+		drop:       make(chan dropRequest),
+
+		sessionCheck: sessionCheckInterval,
 	}
+	h.presence = newPresence(h)
+	return h
 }
 
 func (h *Hub) Run() {
@@ -40,13 +78,39 @@ func (h *Hub) Run() {
 		select {
 		case c := <-h.register:
 			h.clients[c] = true
+			if c.resumeAfter > 0 {
+				h.replay(c)
+			}
 		case c := <-h.unregister:
 			if _, ok := h.clients[c]; ok {
 				delete(h.clients, c)
 				close(c.send)
 			}
+		case d := <-h.drop:
+			for c := range h.clients {
+				if c == d.client || (d.userID != 0 && c.userID == d.userID) {
+					c.closeReason = d.reason
+					delete(h.clients, c)
+					close(c.send)
+				}
+			}
 		case ev := <-h.broadcast:
+			h.seq++
+			ev.ID = h.seq
+			for _, fn := range h.listeners {
+				fn(ev)
+			}
+			if ev.Silent {
+				continue
+			}
+			h.history = append(h.history, ev)
+			if len(h.history) > historySize {
+				h.history = h.history[len(h.history)-historySize:]
+			}
 			for c := range h.clients {
+				if !c.accepts(ev) {
+					continue
+				}
 				select {
 				case c.send <- ev:
 				default:
@@ -62,57 +126,184 @@ func (h *Hub) Broadcast(ev Event) {
 	h.broadcast <- ev
 }
 
-func UpgradeWithAuth(secret string, hub *Hub) fiber.Handler {
+// AddListener registra fn para receber todo evento publicado, já com ID (ex.: webhooks).
+// fn roda no loop do hub e não pode bloquear; registrar antes de Run.
+func (h *Hub) AddListener(fn func(Event)) {
+	h.listeners = append(h.listeners, fn)
+}
+
+// DisconnectUser encerra todas as conexões (WS e SSE) do usuário, ex.: após logout.
+func (h *Hub) DisconnectUser(userID uint, reason string) {
+	h.drop <- dropRequest{userID: userID, reason: reason}
+}
+
+// replay reenvia ao cliente o que ele perdeu; se o histórico não alcança, pede um resync.
+func (h *Hub) replay(c *Client) {
+	// ID à frente do hub indica reinício do servidor: o histórico anterior se perdeu
+	if c.resumeAfter > h.seq || (len(h.history) > 0 && h.history[0].ID > c.resumeAfter+1) {
+		c.send <- Event{ID: h.seq, Type: "resync"}
+		return
+	}
+	for _, ev := range h.history {
+		if ev.ID <= c.resumeAfter || !c.accepts(ev) {
+			continue
+		}
+		select {
+		case c.send <- ev:
+		default:
+			// buffer cheio: o cliente precisa recarregar o estado
+			return
+		}
+	}
+}
+
+// Subscribe registra um assinante sem WebSocket (ex.: SSE), retomando após lastEventID.
+func (h *Hub) Subscribe(userID uint, role string, orgID uint, lastEventID uint64) *Client {
+	c := &Client{send: make(chan Event, historySize), userID: userID, role: role, org: orgID, resumeAfter: lastEventID}
+	h.register <- c
+	h.presence.connect(c)
+	return c
+}
+
+// Unsubscribe encerra um assinante criado com Subscribe.
+func (h *Hub) Unsubscribe(c *Client) {
+	h.presence.disconnect(c)
+	h.unregister <- c
+}
+
+// Events devolve o canal de eventos do cliente; é fechado quando o hub o descarta.
+func (c *Client) Events() <-chan Event {
+	return c.send
+}
+
+// Presence retorna o rastreador de presença associado ao hub.
+func (h *Hub) Presence() *Presence {
+	return h.presence
+}
+
+// SetAudience define como descobrir quem pode ver uma tarefa (usado pelos eventos presence.*).
+func (h *Hub) SetAudience(fn AudienceFunc) {
+	h.presence.setAudience(fn)
+}
+
+// accepts diz se o evento deve ser entregue a este cliente.
+func (c *Client) accepts(ev Event) bool {
+	if c.role == "admin" {
+		return true
+	}
+	if ev.Org != 0 && ev.Org != c.org {
+		return false
+	}
+	if len(ev.To) == 0 {
+		return true
+	}
+	for _, id := range ev.To {
+		if id == c.userID {
+			return true
+		}
+	}
+	return false
+}
+
+// bearerProtocol é o subprotocolo ecoado quando o token vem em Sec-WebSocket-Protocol ("bearer, <jwt>").
+const bearerProtocol = "bearer"
+
+// UpgradeWithAuth autentica o upgrade por ticket de uso único (?ticket=) ou pelo
+// subprotocolo bearer. A conexão cai quando o token expira ou a sessão é revogada.
+func UpgradeWithAuth(secret string, hub *Hub, tickets *TicketStore, revoked auth.Revoked) fiber.Handler {
 	wsHandler := websocket.New(func(conn *websocket.Conn) {
-		client := &Client{conn: conn, send: make(chan Event, 16)}
+		claims, _ := conn.Locals("tokenClaims").(auth.Claims)
+		client := &Client{conn: conn, send: make(chan Event, 16), userID: claims.UserID, role: claims.Role, org: claims.OrgID}
 		hub.register <- client
+		hub.presence.connect(client)
 
+		done := make(chan struct{})
+		writerDone := make(chan struct{})
 		defer func() {
+			close(done)
+			hub.presence.disconnect(client)
 			hub.unregister <- client
+			<-writerDone
 			conn.Close()
 		}()
 
+		go watchSession(hub, client, claims, revoked, done)
+
 		go func() {
+			defer close(writerDone)
 			for ev := range client.send {
 				if err := conn.WriteJSON(ev); err != nil {
 					log.Printf("ws write err: %v", err)
+					conn.Close()
 					return
 				}
 			}
+			// send fechado pelo hub: avisa o motivo e derruba a leitura
+			reason := client.closeReason
+			if reason == "" {
+				reason = "connection closed"
+			}
+			msg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, reason)
+			_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+			conn.Close()
 		}()
 
 		for {
-			if _, _, err := conn.ReadMessage(); err != nil {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
 				break
 			}
+			var in struct {
+				Type    string          `json:"type"`
+				Payload json.RawMessage `json:"payload"`
+			}
+			if err := json.Unmarshal(msg, &in); err != nil {
+				continue
+			}
+			hub.presence.handle(client, in.Type, in.Payload)
 		}
-	})
+	}, websocket.Config{Subprotocols: []string{bearerProtocol}})
 
 	return func(c *fiber.Ctx) error {
 		if !websocket.IsWebSocketUpgrade(c) {
 			return fiber.ErrUpgradeRequired
 		}
-		tokenStr := c.Query("token")
-		if tokenStr == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "missing token"})
-		}
-		tok, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-			if t.Method != jwt.SigningMethodHS256 {
-				return nil, errors.New("invalid signing method")
+		var claims auth.Claims
+		if id := c.Query("ticket"); id != "" {
+			var ok bool
+			claims, ok = tickets.Redeem(id)
+			if !ok {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid ticket"})
 			}
-			return []byte(secret), nil
-		})
-		if err != nil || !tok.Valid {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid token"})
-		}
-		if claims, ok := tok.Claims.(jwt.MapClaims); ok {
-			if sub, ok := claims["sub"].(float64); ok {
-				c.Locals("userID", uint(sub))
+		} else {
+			tokenStr := bearerFromProtocols(c.Get("Sec-WebSocket-Protocol"))
+			if tokenStr == "" {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "missing token"})
 			}
-			if role, ok := claims["role"].(string); ok {
-				c.Locals("userRole", role)
+			var err error
+			claims, err = auth.ParseAccess(tokenStr, secret)
+			if err != nil {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid token"})
 			}
 		}
+		if revoked != nil && revoked(claims.UserID, claims.Version) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "session revoked"})
+		}
+		c.Locals("userID", claims.UserID)
+		c.Locals("userRole", claims.Role)
+		c.Locals("orgID", claims.OrgID)
+		c.Locals("tokenClaims", claims)
 		return wsHandler(c)
 	}
-}
\ No newline at end of file
+}
+
+// bearerFromProtocols extrai o token de "Sec-WebSocket-Protocol: bearer, <jwt>".
+func bearerFromProtocols(header string) string {
+	parts := strings.Split(header, ",")
+	for i := 0; i+1 < len(parts); i++ {
+		if strings.TrimSpace(parts[i]) == bearerProtocol {
+			return strings.TrimSpace(parts[i+1])
+		}
+	}
+	return ""
+}
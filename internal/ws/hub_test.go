@@ -0,0 +1,152 @@
+package ws
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+
+	"goTasks/internal/auth"
+)
+
+const testSecret = "test-secret"
+
+// serve sobe o /ws num servidor de verdade e devolve a URL ws://.
+func serve(t *testing.T, hub *Hub, revoked auth.Revoked) string {
+	t.Helper()
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Get("/ws", UpgradeWithAuth(testSecret, hub, NewTicketStore(time.Minute), revoked))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go app.Listener(ln)
+	t.Cleanup(func() { app.Shutdown() })
+	return "ws://" + ln.Addr().String() + "/ws"
+}
+
+// accessToken assina um access token como auth.CreateToken, mas com a validade escolhida.
+func accessToken(t *testing.T, userID uint, version int, ttl time.Duration) string {
+	t.Helper()
+	s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": userID, "role": "user", "org": 1, "ver": version, "exp": time.Now().Add(ttl).Unix(),
+	}).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// dialBearer conecta mandando o token no subprotocolo ("bearer, <jwt>").
+func dialBearer(url, token string) (*websocket.Conn, *http.Response, error) {
+	d := websocket.Dialer{Subprotocols: []string{bearerProtocol, token}, HandshakeTimeout: 2 * time.Second}
+	return d.Dial(url, nil)
+}
+
+// expectClose lê até o servidor fechar a conexão e confere o motivo.
+func expectClose(t *testing.T, conn *websocket.Conn, reason string) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			var ce *websocket.CloseError
+			if !errors.As(err, &ce) || ce.Code != websocket.ClosePolicyViolation || ce.Text != reason {
+				t.Fatalf("expected close %q, got %v", reason, err)
+			}
+			return
+		}
+	}
+}
+
+func TestBearerSubprotocolIsEchoed(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+	url := serve(t, hub, nil)
+
+	conn, resp, err := dialBearer(url, accessToken(t, 1, 0, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	// só "bearer" volta: o token não é ecoado
+	if got := resp.Header.Get("Sec-WebSocket-Protocol"); got != bearerProtocol || conn.Subprotocol() != bearerProtocol {
+		t.Fatalf("expected the bearer subprotocol to be echoed, got %q", got)
+	}
+
+	refresh, _ := auth.CreateRefreshToken(1, "user", 1, 0, testSecret)
+	if _, resp, err := dialBearer(url, refresh); err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("a refresh token must not open the socket, got %v", err)
+	}
+}
+
+// until lê os eventos do cliente até chegar um do tipo typ e devolve todos os lidos.
+func until(t *testing.T, c *Client, typ string) []Event {
+	t.Helper()
+	var seen []Event
+	deadline := time.After(2 * time.Second)
+	for {
+		select {
+		case ev, ok := <-c.Events():
+			if !ok {
+				t.Fatalf("channel closed before %s", typ)
+			}
+			seen = append(seen, ev)
+			if ev.Type == typ {
+				return seen
+			}
+		case <-deadline:
+			t.Fatalf("no %s event, got %v", typ, seen)
+		}
+	}
+}
+
+func TestResumeReplaysOnlyLaterEventsOfTheUser(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+	// o admin recebe tudo: serve para saber o ID de cada evento e quando todos já estão no histórico
+	admin := hub.Subscribe(99, "admin", 0, 0)
+	defer hub.Unsubscribe(admin)
+	for _, ev := range []Event{
+		{Type: "ana.1", To: []uint{1}, Org: 1},
+		{Type: "org1.1", Org: 1},
+		{Type: "ana.2", To: []uint{1}, Org: 1},
+		{Type: "bob", To: []uint{2}, Org: 1},
+		{Type: "org2", Org: 2},
+		{Type: "ana.other-org", To: []uint{1}, Org: 2},
+		{Type: "ana.silent", To: []uint{1}, Org: 1, Silent: true},
+		{Type: "ana.3", To: []uint{1}, Org: 1},
+		{Type: "org1.2", Org: 1},
+		{Type: "everyone"},
+	} {
+		hub.Broadcast(ev)
+	}
+	ids := map[string]uint64{}
+	for _, ev := range until(t, admin, "everyone") {
+		ids[ev.Type] = ev.ID
+	}
+
+	// ana (usuário 1, org 1) volta depois de ter visto ana.2
+	ana := hub.Subscribe(1, "user", 1, ids["ana.2"])
+	defer hub.Unsubscribe(ana)
+	hub.Broadcast(Event{Type: "end", To: []uint{1}, Org: 1})
+	var got []string
+	for _, ev := range until(t, ana, "end") {
+		if strings.HasPrefix(ev.Type, "presence.") {
+			continue
+		}
+		if ev.ID <= ids["ana.2"] && ev.Type != "end" {
+			t.Fatalf("replayed event %d at or before Last-Event-ID %d", ev.ID, ids["ana.2"])
+		}
+		got = append(got, ev.Type)
+	}
+	if want := "[ana.3 org1.2 everyone end]"; fmt.Sprint(got) != want {
+		t.Fatalf("resume must replay only later events for the user and org, got %v want %s", got, want)
+	}
+}
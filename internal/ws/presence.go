@@ -0,0 +1,261 @@
+package ws
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+)
+
+// typingTTL é quanto tempo um indicador de digitação vale sem ser renovado.
+const typingTTL = 6 * time.Second
+
+// AudienceFunc devolve os usuários autorizados a ver uma tarefa.
+type AudienceFunc func(taskID uint) ([]uint, error)
+
+type Viewer struct {
+	UserID uint   `json:"userId"`
+	Mode   string `json:"mode"` // "view" | "edit"
+	Typing bool   `json:"typing"`
+}
+
+type clientPresence struct {
+	taskID   uint
+	mode     string
+	audience []uint
+	typing   *time.Timer
+}
+
+// Presence rastreia usuários online e qual tarefa cada conexão está vendo/editando.
+type Presence struct {
+	mu       sync.Mutex
+	hub      *Hub
+	audience AudienceFunc
+	online   map[uint]int // userID -> conexões abertas
+	state    map[*Client]*clientPresence
+	// typingTTL é a validade do indicador de digitação (os testes encurtam)
+	typingTTL time.Duration
+}
+
+func newPresence(h *Hub) *Presence {
+	return &Presence{
+		hub:    h,
+		online: make(map[uint]int),
+		state:  make(map[*Client]*clientPresence),
+
+		typingTTL: typingTTL,
+	}
+}
+
+func (p *Presence) setAudience(fn AudienceFunc) {
+	p.mu.Lock()
+	p.audience = fn
+	p.mu.Unlock()
+}
+
+// Online lista os usuários com pelo menos uma conexão aberta.
+func (p *Presence) Online() []uint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]uint, 0, len(p.online))
+	for uid := range p.online {
+		out = append(out, uid)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// Viewers lista quem está com a tarefa aberta; com várias abas, "edit" prevalece sobre "view".
+func (p *Presence) Viewers(taskID uint) []Viewer {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	byUser := make(map[uint]*Viewer)
+	for c, st := range p.state {
+		if st.taskID != taskID {
+			continue
+		}
+		v, ok := byUser[c.userID]
+		if !ok {
+			v = &Viewer{UserID: c.userID, Mode: st.mode}
+			byUser[c.userID] = v
+		}
+		if st.mode == "edit" {
+			v.Mode = "edit"
+		}
+		if st.typing != nil {
+			v.Typing = true
+		}
+	}
+	out := make([]Viewer, 0, len(byUser))
+	for _, v := range byUser {
+		out = append(out, *v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].UserID < out[j].UserID })
+	return out
+}
+
+func (p *Presence) connect(c *Client) {
+	p.mu.Lock()
+	p.online[c.userID]++
+	first := p.online[c.userID] == 1
+	p.mu.Unlock()
+	if first {
+		p.hub.Broadcast(Event{Type: "presence.online", Payload: map[string]uint{"userId": c.userID}, Org: c.org})
+	}
+}
+
+func (p *Presence) disconnect(c *Client) {
+	p.mu.Lock()
+	events := p.leaveLocked(c)
+	p.online[c.userID]--
+	if p.online[c.userID] <= 0 {
+		delete(p.online, c.userID)
+		events = append(events, Event{Type: "presence.offline", Payload: map[string]uint{"userId": c.userID}, Org: c.org})
+	}
+	p.mu.Unlock()
+	p.emit(events)
+}
+
+// handle processa mensagens presence.* enviadas pelo cliente.
+func (p *Presence) handle(c *Client, typ string, raw json.RawMessage) {
+	var in struct {
+		TaskID uint  `json:"taskId"`
+		Typing *bool `json:"typing"`
+	}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &in); err != nil {
+			return
+		}
+	}
+
+	switch typ {
+	case "presence.view", "presence.edit":
+		if in.TaskID == 0 {
+			return
+		}
+		audience, ok := p.authorize(c, in.TaskID)
+		if !ok {
+			return
+		}
+		mode := "view"
+		if typ == "presence.edit" {
+			mode = "edit"
+		}
+		p.mu.Lock()
+		var events []Event
+		if st, ok := p.state[c]; ok && st.taskID != in.TaskID {
+			events = p.leaveLocked(c)
+		}
+		st, ok := p.state[c]
+		if !ok {
+			st = &clientPresence{taskID: in.TaskID}
+			p.state[c] = st
+		}
+		st.mode = mode
+		st.audience = audience
+		events = append(events, Event{
+			Type:    "presence.viewing",
+			Payload: map[string]interface{}{"taskId": in.TaskID, "userId": c.userID, "mode": mode},
+			To:      audience,
+		})
+		p.mu.Unlock()
+		p.emit(events)
+
+	case "presence.leave":
+		p.mu.Lock()
+		events := p.leaveLocked(c)
+		p.mu.Unlock()
+		p.emit(events)
+
+	case "presence.typing":
+		typing := in.Typing == nil || *in.Typing
+		p.mu.Lock()
+		st, ok := p.state[c]
+		if !ok || (in.TaskID != 0 && st.taskID != in.TaskID) {
+			p.mu.Unlock()
+			return
+		}
+		var events []Event
+		if typing {
+			if st.typing == nil {
+				events = append(events, typingEvent(c.userID, st, true))
+				st.typing = time.AfterFunc(p.typingTTL, func() { p.expireTyping(c, st) })
+			} else {
+				st.typing.Reset(p.typingTTL)
+			}
+		} else if st.typing != nil {
+			st.typing.Stop()
+			st.typing = nil
+			events = append(events, typingEvent(c.userID, st, false))
+		}
+		p.mu.Unlock()
+		p.emit(events)
+	}
+}
+
+func (p *Presence) authorize(c *Client, taskID uint) ([]uint, bool) {
+	p.mu.Lock()
+	fn := p.audience
+	p.mu.Unlock()
+	if fn == nil {
+		return nil, false
+	}
+	audience, err := fn(taskID)
+	if err != nil || len(audience) == 0 {
+		return nil, false
+	}
+	if c.role == "admin" {
+		return audience, true
+	}
+	for _, id := range audience {
+		if id == c.userID {
+			return audience, true
+		}
+	}
+	return nil, false
+}
+
+func (p *Presence) expireTyping(c *Client, st *clientPresence) {
+	p.mu.Lock()
+	cur, ok := p.state[c]
+	if !ok || cur != st || st.typing == nil {
+		p.mu.Unlock()
+		return
+	}
+	st.typing = nil
+	ev := typingEvent(c.userID, st, false)
+	p.mu.Unlock()
+	p.hub.Broadcast(ev)
+}
+
+// leaveLocked remove o cliente da tarefa atual; p.mu deve estar travado.
+func (p *Presence) leaveLocked(c *Client) []Event {
+	st, ok := p.state[c]
+	if !ok {
+		return nil
+	}
+	delete(p.state, c)
+	if st.typing != nil {
+		st.typing.Stop()
+		st.typing = nil
+	}
+	return []Event{{
+		Type:    "presence.left",
+		Payload: map[string]uint{"taskId": st.taskID, "userId": c.userID},
+		To:      st.audience,
+	}}
+}
+
+func (p *Presence) emit(events []Event) {
+	for _, ev := range events {
+		p.hub.Broadcast(ev)
+	}
+}
+
+func typingEvent(userID uint, st *clientPresence, typing bool) Event {
+	return Event{
+		Type:    "presence.typing",
+		Payload: map[string]interface{}{"taskId": st.taskID, "userId": userID, "typing": typing},
+		To:      st.audience,
+	}
+}
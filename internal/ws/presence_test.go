@@ -0,0 +1,127 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// presenceHub sobe um hub em que a tarefa 5 é vista só pelos usuários 1 e 2.
+func presenceHub(t *testing.T) *Hub {
+	t.Helper()
+	hub := NewHub()
+	hub.presence.typingTTL = 30 * time.Millisecond
+	hub.SetAudience(func(taskID uint) ([]uint, error) {
+		if taskID == 5 {
+			return []uint{1, 2}, nil
+		}
+		return nil, nil
+	})
+	go hub.Run()
+	return hub
+}
+
+func send(hub *Hub, c *Client, typ string, payload interface{}) {
+	raw, _ := json.Marshal(payload)
+	hub.presence.handle(c, typ, raw)
+}
+
+func typing(t *testing.T, ev Event) bool {
+	t.Helper()
+	p, ok := ev.Payload.(map[string]interface{})
+	if !ok {
+		t.Fatalf("unexpected typing payload %#v", ev.Payload)
+	}
+	return p["typing"].(bool)
+}
+
+func TestTypingExpiresAfterTTL(t *testing.T) {
+	hub := presenceHub(t)
+	ana, bob := hub.Subscribe(1, "user", 1, 0), hub.Subscribe(2, "user", 1, 0)
+	defer hub.Unsubscribe(bob)
+	defer hub.Unsubscribe(ana)
+
+	send(hub, ana, "presence.view", map[string]uint{"taskId": 5})
+	send(hub, ana, "presence.typing", map[string]uint{"taskId": 5})
+	seen := until(t, bob, "presence.typing")
+	if !typing(t, seen[len(seen)-1]) {
+		t.Fatal("expected typing=true first")
+	}
+	if v := hub.Presence().Viewers(5); len(v) != 1 || !v[0].Typing {
+		t.Fatalf("expected ana typing on task 5, got %+v", v)
+	}
+	// sem renovação, o indicador some sozinho
+	seen = until(t, bob, "presence.typing")
+	if typing(t, seen[len(seen)-1]) {
+		t.Fatal("typing must expire after the TTL")
+	}
+	if v := hub.Presence().Viewers(5); len(v) != 1 || v[0].Typing {
+		t.Fatalf("expected ana still viewing but not typing, got %+v", v)
+	}
+}
+
+func TestDisconnectCleansUpPresence(t *testing.T) {
+	hub := presenceHub(t)
+	ana, bob := hub.Subscribe(1, "user", 1, 0), hub.Subscribe(2, "user", 1, 0)
+	defer hub.Unsubscribe(bob)
+
+	send(hub, ana, "presence.edit", map[string]uint{"taskId": 5})
+	send(hub, ana, "presence.typing", map[string]uint{"taskId": 5})
+	until(t, bob, "presence.typing")
+	hub.Unsubscribe(ana)
+
+	seen := until(t, bob, "presence.offline")
+	left := false
+	for _, ev := range seen {
+		left = left || ev.Type == "presence.left"
+	}
+	if !left {
+		t.Fatalf("disconnect must announce presence.left, got %v", seen)
+	}
+	if v := hub.Presence().Viewers(5); len(v) != 0 {
+		t.Fatalf("disconnected client must leave the task, got %+v", v)
+	}
+	if online := hub.Presence().Online(); len(online) != 1 || online[0] != 2 {
+		t.Fatalf("expected only bob online, got %v", online)
+	}
+	// o timer de digitação foi parado junto: nenhum typing=false chega depois
+	hub.Broadcast(Event{Type: "end", To: []uint{2}})
+	for _, ev := range until(t, bob, "end") {
+		if ev.Type == "presence.typing" {
+			t.Fatalf("typing timer must stop on disconnect, got %+v", ev)
+		}
+	}
+}
+
+func TestPresenceEventsFollowTheTaskAudience(t *testing.T) {
+	hub := presenceHub(t)
+	ana, bob := hub.Subscribe(1, "user", 1, 0), hub.Subscribe(2, "user", 1, 0)
+	carol := hub.Subscribe(3, "user", 1, 0) // mesma organização, sem acesso à tarefa 5
+	dan := hub.Subscribe(4, "user", 2, 0)   // outra organização
+	for _, c := range []*Client{ana, bob, carol, dan} {
+		defer hub.Unsubscribe(c)
+	}
+
+	send(hub, carol, "presence.view", map[string]uint{"taskId": 5})
+	send(hub, ana, "presence.view", map[string]uint{"taskId": 5})
+	send(hub, ana, "presence.typing", map[string]uint{"taskId": 5})
+	send(hub, ana, "presence.leave", nil)
+	until(t, bob, "presence.left")
+	if v := hub.Presence().Viewers(5); len(v) != 0 {
+		t.Fatalf("carol cannot view a task outside her audience, got %+v", v)
+	}
+
+	hub.Broadcast(Event{Type: "end"})
+	for _, c := range []*Client{carol, dan} {
+		for _, ev := range until(t, c, "end") {
+			switch ev.Type {
+			case "presence.viewing", "presence.typing", "presence.left":
+				t.Fatalf("user %d outside the audience got %s", c.userID, ev.Type)
+			case "presence.online":
+				if p := ev.Payload.(map[string]uint); c == dan && p["userId"] != 4 {
+					t.Fatalf("presence.online must stay in the organization, dan got %v", p)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package ws
+
+import (
+	"time"
+
+	"goTasks/internal/auth"
+)
+
+// sessionCheckInterval define a frequência de reverificação de revogação em conexões longas.
+const sessionCheckInterval = time.Minute
+
+// watchSession derruba o cliente quando o token expira ou a sessão é revogada.
+func watchSession(hub *Hub, c *Client, claims auth.Claims, revoked auth.Revoked, done <-chan struct{}) {
+	expire := time.NewTimer(time.Until(claims.ExpiresAt))
+	defer expire.Stop()
+	check := time.NewTicker(hub.sessionCheck)
+	defer check.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-expire.C:
+			hub.drop <- dropRequest{client: c, reason: "token expired"}
+			return
+		case <-check.C:
+			if revoked != nil && revoked(claims.UserID, claims.Version) {
+				hub.drop <- dropRequest{client: c, reason: "session revoked"}
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package ws
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatchSessionClosesOnExpiry(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+	url := serve(t, hub, nil)
+
+	// exp tem resolução de segundos: 2s garante um token ainda válido no upgrade
+	conn, _, err := dialBearer(url, accessToken(t, 1, 0, 2*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	expectClose(t, conn, "token expired")
+}
+
+func TestWatchSessionClosesOnVersionBump(t *testing.T) {
+	var version atomic.Int32 // User.TokenVersion
+	revoked := func(_ uint, v int) bool { return int32(v) < version.Load() }
+	hub := NewHub()
+	hub.sessionCheck = 20 * time.Millisecond
+	go hub.Run()
+	url := serve(t, hub, revoked)
+
+	conn, _, err := dialBearer(url, accessToken(t, 1, 0, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	version.Store(1) // ex.: logout ou troca de senha
+	expectClose(t, conn, "session revoked")
+}
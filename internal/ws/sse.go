@@ -0,0 +1,93 @@
+package ws
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"goTasks/internal/auth"
+)
+
+// sseHeartbeat mantém a conexão viva atrás de proxies que derrubam streams ociosos.
+const sseHeartbeat = 15 * time.Second
+
+// ServeSSE expõe os eventos do hub como text/event-stream, com a mesma filtragem por usuário do /ws.
+// Espera as claims nos Locals (RequireJWT) e aceita Last-Event-ID para retomada.
+// Assim como no /ws, o stream termina quando o token expira ou a sessão é revogada.
+func ServeSSE(hub *Hub, revoked auth.Revoked) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, ok := c.Locals("tokenClaims").(auth.Claims)
+		if !ok || claims.UserID == 0 {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+		}
+
+		lastID := c.Get("Last-Event-ID")
+		if lastID == "" {
+			lastID = c.Query("lastEventId")
+		}
+		var after uint64
+		if lastID != "" {
+			n, err := strconv.ParseUint(lastID, 10, 64)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid Last-Event-ID"})
+			}
+			after = n
+		}
+
+		c.Set("Content-Type", "text/event-stream")
+		c.Set("Cache-Control", "no-cache")
+		c.Set("Connection", "keep-alive")
+		c.Set("X-Accel-Buffering", "no")
+
+		client := hub.Subscribe(claims.UserID, claims.Role, claims.OrgID, after)
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			done := make(chan struct{})
+			defer hub.Unsubscribe(client)
+			defer close(done)
+			go watchSession(hub, client, claims, revoked, done)
+
+			// sugere ao EventSource o intervalo de reconexão
+			fmt.Fprintf(w, "retry: 3000\n\n")
+			if err := w.Flush(); err != nil {
+				return
+			}
+
+			ticker := time.NewTicker(sseHeartbeat)
+			defer ticker.Stop()
+			for {
+				select {
+				case ev, ok := <-client.Events():
+					if !ok {
+						if client.closeReason != "" {
+							fmt.Fprintf(w, "event: close\ndata: %q\n\n", client.closeReason)
+							w.Flush()
+						}
+						return
+					}
+					if err := writeSSE(w, ev); err != nil {
+						return
+					}
+				case <-ticker.C:
+					fmt.Fprintf(w, ": ping\n\n")
+				}
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		})
+		return nil
+	}
+}
+
+func writeSSE(w *bufio.Writer, ev Event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, data)
+	return err
+}
@@ -0,0 +1,82 @@
+package ws
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"goTasks/internal/auth"
+)
+
+// TicketStore guarda tickets de uso único para autenticar o upgrade do /ws sem expor o JWT na URL.
+type TicketStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	tickets map[string]ticket
+}
+
+type ticket struct {
+	claims  auth.Claims
+	expires time.Time
+}
+
+func NewTicketStore(ttl time.Duration) *TicketStore {
+	return &TicketStore{ttl: ttl, tickets: make(map[string]ticket)}
+}
+
+// Issue cria um ticket atrelado às claims do token que o solicitou.
+func (s *TicketStore) Issue(claims auth.Claims) (string, time.Time, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", time.Time{}, err
+	}
+	id := hex.EncodeToString(b)
+	now := time.Now()
+	expires := now.Add(s.ttl)
+	if !claims.ExpiresAt.IsZero() && claims.ExpiresAt.Before(expires) {
+		expires = claims.ExpiresAt
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, t := range s.tickets {
+		if now.After(t.expires) {
+			delete(s.tickets, k)
+		}
+	}
+	s.tickets[id] = ticket{claims: claims, expires: expires}
+	return id, expires, nil
+}
+
+// Redeem consome o ticket; um segundo uso ou um ticket vencido falham.
+func (s *TicketStore) Redeem(id string) (auth.Claims, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tickets[id]
+	if !ok {
+		return auth.Claims{}, false
+	}
+	delete(s.tickets, id)
+	if time.Now().After(t.expires) {
+		return auth.Claims{}, false
+	}
+	return t.claims, true
+}
+
+// IssueHandler atende POST /api/ws/ticket (requer RequireJWT).
+func (s *TicketStore) IssueHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, ok := c.Locals("tokenClaims").(auth.Claims)
+		if !ok || claims.UserID == 0 {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+		}
+		id, expires, err := s.Issue(claims)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal error"})
+		}
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"ticket": id, "expiresAt": expires})
+	}
+}
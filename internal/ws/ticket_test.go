@@ -0,0 +1,36 @@
+package ws
+
+import (
+	"testing"
+	"time"
+
+	"goTasks/internal/auth"
+)
+
+func TestTicketIsSingleUseAndExpires(t *testing.T) {
+	s := NewTicketStore(50 * time.Millisecond)
+	claims := auth.Claims{UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}
+
+	id, _, err := s.Issue(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := s.Redeem(id); !ok || got.UserID != 1 {
+		t.Fatalf("first redeem: %+v %v", got, ok)
+	}
+	if _, ok := s.Redeem(id); ok {
+		t.Fatal("a ticket must be single-use")
+	}
+
+	id, _, _ = s.Issue(claims)
+	time.Sleep(80 * time.Millisecond)
+	if _, ok := s.Redeem(id); ok {
+		t.Fatal("an expired ticket must be rejected")
+	}
+
+	// o ticket nunca vale mais que o token que o pediu
+	soon := time.Now().Add(time.Second)
+	if _, expires, _ := NewTicketStore(time.Hour).Issue(auth.Claims{UserID: 1, ExpiresAt: soon}); !expires.Equal(soon) {
+		t.Fatalf("ticket must expire with the token, got %v want %v", expires, soon)
+	}
+}
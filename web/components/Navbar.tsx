@@ -22,7 +22,14 @@ export default function Navbar() {
         <div className="flex items-center gap-3">
           <NotificationsBell apiUrl={apiUrl} token={token} />
           <button
-            onClick={() => {
+            onClick={async () => {
+              if (token) {
+                // revoga a sessão no servidor (derruba também o /ws)
+                await fetch(`${apiUrl}/api/auth/logout`, {
+                  method: 'POST',
+                  headers: { Authorization: `Bearer ${token}` },
+                }).catch(() => {});
+              }
               localStorage.removeItem('token');
               router.push('/login');
             }}
@@ -16,7 +16,7 @@ type Props = {
 export default function NotificationsBell({ apiUrl, token }: Props) {
   const [open, setOpen] = useState(false);
   const [items, setItems] = useState<Notification[]>([]);
-  const unreadCount = useMemo(() => items.filter(i => !i.read).length, [items]);
+  const [unreadCount, setUnreadCount] = useState(0);
 
   const headers = useMemo(
     () => (token ? { Authorization: `Bearer ${token}` } : {}),
@@ -29,7 +29,12 @@ export default function NotificationsBell({ apiUrl, token }: Props) {
     const res = await fetch(url, { headers });
     if (res.ok) {
       const data = await res.json();
-      setItems(Array.isArray(data) ? data : []);
+      setItems(Array.isArray(data?.items) ? data.items : []);
+    }
+    const countRes = await fetch(`${apiUrl}/api/notifications/unread-count`, { headers });
+    if (countRes.ok) {
+      const { count } = await countRes.json();
+      setUnreadCount(count ?? 0);
     }
   };
 
@@ -44,6 +49,23 @@ export default function NotificationsBell({ apiUrl, token }: Props) {
     });
     if (res.ok) {
       setItems(prev => prev.map(i => (i.id === id ? { ...i, read: true } : i)));
+      setUnreadCount(c => Math.max(0, c - 1));
+    }
+  };
+
+  const markAllRead = async () => {
+    if (!token) return;
+    const res = await fetch(`${apiUrl}/api/notifications/read-all`, {
+      method: 'POST',
+      headers: {
+        'Content-Type': 'application/json',
+        ...(token ? { Authorization: `Bearer ${token}` } : {}),
+      },
+      body: JSON.stringify({ before: new Date().toISOString() }),
+    });
+    if (res.ok) {
+      setItems(prev => prev.map(i => ({ ...i, read: true })));
+      setUnreadCount(0);
     }
   };
 
@@ -72,6 +94,13 @@ export default function NotificationsBell({ apiUrl, token }: Props) {
 
       {open && (
         <div className="absolute right-0 mt-2 w-80 rounded border bg-white shadow-lg">
+          {unreadCount > 0 && (
+            <div className="flex justify-end border-b px-3 py-2">
+              <button className="text-xs text-blue-600 hover:underline" onClick={markAllRead}>
+                Marcar todas como lidas
+              </button>
+            </div>
+          )}
           <div className="max-h-80 overflow-auto">
             {items.length === 0 ? (
               <div className="px-3 py-4 text-sm text-gray-600">Sem notificações.</div>
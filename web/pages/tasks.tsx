@@ -128,14 +128,26 @@ function TasksClientComponent() {
 
   useEffect(() => {
     if (!token) return;
-    const ws = new WebSocket(`${API_URL.replace('http', 'ws')}/ws?token=${token}`);
-    ws.onmessage = (event) => {
-      const msg = JSON.parse(event.data);
-      if (msg.type?.startsWith('task.')) {
-        fetchTasks(); // Refetch on any task update
-      }
+    let ws: WebSocket | null = null;
+    let cancelled = false;
+    // Ticket de uso único: o JWT não vai para a query string (logs de acesso/proxy)
+    fetch(`${API_URL}/api/ws/ticket`, { method: 'POST', headers })
+      .then(res => (res.ok ? res.json() : Promise.reject(res)))
+      .then(({ ticket }) => {
+        if (cancelled) return;
+        ws = new WebSocket(`${API_URL.replace('http', 'ws')}/ws?ticket=${ticket}`);
+        ws.onmessage = (event) => {
+          const msg = JSON.parse(event.data);
+          if (msg.type?.startsWith('task.')) {
+            fetchTasks(); // Refetch on any task update
+          }
+        };
+      })
+      .catch(() => {});
+    return () => {
+      cancelled = true;
+      ws?.close();
     };
-    return () => ws.close();
   // eslint-disable-next-line react-hooks/exhaustive-deps
   }, [token]);
 